@@ -0,0 +1,1109 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import "time"
+
+// Initialize initializes NVML.
+// Call this before calling any other methods.
+func Initialize() error {
+	return backend.Initialize()
+}
+
+// Shutdown shuts down NVML.
+// Call this once NVML is no longer being used.
+func Shutdown() error {
+	return backend.Shutdown()
+}
+
+// SystemDriverVersion returns the the driver version on the system.
+func SystemDriverVersion() (string, error) {
+	return backend.SystemDriverVersion()
+}
+
+// SystemNVMLVersion returns the version of the NVML library.
+func SystemNVMLVersion() (string, error) {
+	return backend.SystemNVMLVersion()
+}
+
+// SystemCudaDriverVersion returns the version of the CUDA driver on the
+// system. This is the same value that cuDriverGetVersion() would return, i.e.
+// 1000*major + 10*minor, e.g. 9010 for CUDA 9.1.
+func SystemCudaDriverVersion() (int, error) {
+	return backend.SystemCudaDriverVersion()
+}
+
+// SystemProcessName returns the name of the process with the given PID.
+func SystemProcessName(pid uint) (string, error) {
+	return backend.SystemProcessName(pid)
+}
+
+// DeviceCount returns the number of nvidia devices on the system.
+func DeviceCount() (uint, error) {
+	return backend.DeviceCount()
+}
+
+// Device is the handle for the device.
+// This handle is obtained by calling DeviceHandleByIndex().
+type Device struct {
+	b DeviceBackend
+	// parent is the Backend that created the handle.
+	parent Backend
+}
+
+// DeviceHandleByIndex returns the device handle for a particular index.
+// The indices range from 0 to DeviceCount()-1. The order in which NVML
+// enumerates devices has no guarantees of consistency between reboots.
+func DeviceHandleByIndex(idx uint) (Device, error) {
+	dev, err := backend.DeviceHandleByIndex(idx)
+	return Device{dev, backend}, err
+}
+
+// DeviceHandleByPciBusID returns the device handle for the device with the
+// given PCI bus ID, in the same format as PciInfo.BusID.
+// Unlike indices, PCI bus IDs are stable across GPU removal and rediscovery,
+// so this can be used to re-establish a handle after DiscoverGPUs().
+func DeviceHandleByPciBusID(busID string) (Device, error) {
+	dev, err := backend.DeviceHandleByPciBusID(busID)
+	return Device{dev, backend}, err
+}
+
+// MinorNumber returns the minor number for the device.
+// The minor number for the device is such that the Nvidia device node
+// file for each GPU will have the form /dev/nvidia[minor number].
+func (d Device) MinorNumber() (uint, error) {
+	if d.b == nil {
+		return 0, errInvalidHandle
+	}
+	return d.b.MinorNumber()
+}
+
+// UUID returns the globally unique immutable UUID associated with this device.
+func (d Device) UUID() (string, error) {
+	if d.b == nil {
+		return "", errInvalidHandle
+	}
+	return d.b.UUID()
+}
+
+// Name returns the product name of the device.
+func (d Device) Name() (string, error) {
+	if d.b == nil {
+		return "", errInvalidHandle
+	}
+	return d.b.Name()
+}
+
+// MemoryInfo returns the total and used memory (in bytes) of the device.
+func (d Device) MemoryInfo() (uint64, uint64, error) {
+	if d.b == nil {
+		return 0, 0, errInvalidHandle
+	}
+	return d.b.MemoryInfo()
+}
+
+// UtilizationRates returns the percent of time over the past sample period during which:
+// utilization.gpu: one or more kernels were executing on the GPU.
+// utilization.memory: global (device) memory was being read or written.
+func (d Device) UtilizationRates() (uint, uint, error) {
+	if d.b == nil {
+		return 0, 0, errInvalidHandle
+	}
+	return d.b.UtilizationRates()
+}
+
+// PowerUsage returns the power usage for this GPU and its associated circuitry
+// in milliwatts. The reading is accurate to within +/- 5% of current power draw.
+func (d Device) PowerUsage() (uint, error) {
+	if d.b == nil {
+		return 0, errInvalidHandle
+	}
+	return d.b.PowerUsage()
+}
+
+// AveragePowerUsage returns the power usage for this GPU and its associated circuitry
+// in milliwatts averaged over the samples collected in the last `since` duration.
+// ErrNoSamples is returned if no samples were collected in that duration.
+func (d Device) AveragePowerUsage(since time.Duration) (uint, error) {
+	if d.b == nil {
+		return 0, errInvalidHandle
+	}
+	return d.averageSample(TotalPowerSamples, since)
+}
+
+// AverageGPUUtilization returns the utilization.gpu metric (percent of time
+// one of more kernels were executing on the GPU) averaged over the samples
+// collected in the last `since` duration.
+// ErrNoSamples is returned if no samples were collected in that duration.
+func (d Device) AverageGPUUtilization(since time.Duration) (uint, error) {
+	if d.b == nil {
+		return 0, errInvalidHandle
+	}
+	return d.averageSample(GPUUtilizationSamples, since)
+}
+
+// averageSample returns the average of the samples of the given type collected
+// in the last `since` duration.
+//
+// In my experiments, I found that the GPUUtilizationSamples buffer stores 100
+// samples that are uniformly spread with ~6 samples per second. So the buffer
+// stores last ~16s of data.
+// The TotalPowerSamples buffer stores 120 samples, but in different runs I
+// noticed them to be non-uniformly separated. Sometimes 120 samples only
+// consisted of 10s of data and sometimes they were spread over 60s.
+func (d Device) averageSample(samplingType SamplingType, since time.Duration) (uint, error) {
+	samples, err := d.Samples(samplingType, since)
+	if err != nil {
+		return 0, err
+	}
+	if len(samples) == 0 {
+		return 0, ErrNoSamples
+	}
+	var sum float64
+	for _, sample := range samples {
+		sum += sample.Value
+	}
+	return uint(sum / float64(len(samples))), nil
+}
+
+// Samples returns the samples of the given type that the driver collected in
+// the last `since` duration, oldest first.
+// The driver keeps a limited buffer of samples per type, e.g. ~16s worth of
+// GPU utilization samples, so a long `since` does not return older data.
+func (d Device) Samples(samplingType SamplingType, since time.Duration) ([]Sample, error) {
+	if d.b == nil {
+		return nil, errInvalidHandle
+	}
+	return d.b.Samples(samplingType, since)
+}
+
+// Temperature returns the temperature for this GPU in Celsius.
+func (d Device) Temperature() (uint, error) {
+	if d.b == nil {
+		return 0, errInvalidHandle
+	}
+	return d.b.Temperature()
+}
+
+// FanSpeed returns the temperature for this GPU in the percentage of its full
+// speed, with 100 being the maximum.
+func (d Device) FanSpeed() (uint, error) {
+	if d.b == nil {
+		return 0, errInvalidHandle
+	}
+	return d.b.FanSpeed()
+}
+
+// EncoderUtilization returns the percent of time over the last sample period during which the GPU video encoder was being used.
+// The sampling period is variable and is returned in the second return argument in microseconds.
+func (d Device) EncoderUtilization() (uint, uint, error) {
+	if d.b == nil {
+		return 0, 0, errInvalidHandle
+	}
+	return d.b.EncoderUtilization()
+}
+
+// DecoderUtilization returns the percent of time over the last sample period during which the GPU video decoder was being used.
+// The sampling period is variable and is returned in the second return argument in microseconds.
+func (d Device) DecoderUtilization() (uint, uint, error) {
+	if d.b == nil {
+		return 0, 0, errInvalidHandle
+	}
+	return d.b.DecoderUtilization()
+}
+
+// VbiosVersion returns the VBIOS version of the device.
+func (d Device) VbiosVersion() (string, error) {
+	if d.b == nil {
+		return "", errInvalidHandle
+	}
+	return d.b.VbiosVersion()
+}
+
+// InforomImageVersion returns the global InfoROM image version of the
+// device. Like the VBIOS version, it identifies the firmware the board was
+// flashed with.
+func (d Device) InforomImageVersion() (string, error) {
+	if d.b == nil {
+		return "", errInvalidHandle
+	}
+	return d.b.InforomImageVersion()
+}
+
+// Serial returns the globally unique board serial number associated with this
+// device's board. It matches the serial number tag physically attached to the
+// board.
+func (d Device) Serial() (string, error) {
+	if d.b == nil {
+		return "", errInvalidHandle
+	}
+	return d.b.Serial()
+}
+
+// BoardPartNumber returns the part number of the board the device is on.
+func (d Device) BoardPartNumber() (string, error) {
+	if d.b == nil {
+		return "", errInvalidHandle
+	}
+	return d.b.BoardPartNumber()
+}
+
+// Brand returns the brand of the device.
+func (d Device) Brand() (BrandType, error) {
+	if d.b == nil {
+		return BrandUnknown, errInvalidHandle
+	}
+	return d.b.Brand()
+}
+
+// BoardID returns the board ID of the device.
+// Devices on the same multi-GPU board share the same board ID.
+func (d Device) BoardID() (uint, error) {
+	if d.b == nil {
+		return 0, errInvalidHandle
+	}
+	return d.b.BoardID()
+}
+
+// MultiGPUBoard returns whether the device is on a multi-GPU board.
+func (d Device) MultiGPUBoard() (bool, error) {
+	if d.b == nil {
+		return false, errInvalidHandle
+	}
+	return d.b.MultiGPUBoard()
+}
+
+// Index returns the NVML index of the device. This is the index that can be
+// passed to DeviceHandleByIndex() to get the handle for this device.
+func (d Device) Index() (uint, error) {
+	if d.b == nil {
+		return 0, errInvalidHandle
+	}
+	return d.b.Index()
+}
+
+// BridgeChipInfo returns the hierarchy of bridge chips on the board the device
+// is on. The bridge immediately above the device is at index 0, its parent at
+// index 1 and so forth.
+// Only applicable to multi-GPU boards.
+func (d Device) BridgeChipInfo() ([]BridgeChip, error) {
+	if d.b == nil {
+		return nil, errInvalidHandle
+	}
+	return d.b.BridgeChipInfo()
+}
+
+// ComputeMode returns the current compute mode of the device. The compute mode
+// determines whether multiple processes can create compute contexts on the
+// device at the same time.
+func (d Device) ComputeMode() (ComputeMode, error) {
+	if d.b == nil {
+		return ComputeModeDefault, errInvalidHandle
+	}
+	return d.b.ComputeMode()
+}
+
+// PersistenceMode returns whether persistence mode is enabled for the device.
+// When enabled the driver remains loaded even when no active clients exist.
+// Only available on Linux.
+func (d Device) PersistenceMode() (bool, error) {
+	if d.b == nil {
+		return false, errInvalidHandle
+	}
+	return d.b.PersistenceMode()
+}
+
+// DisplayMode returns whether a physical display is currently connected to
+// any of the device's connectors.
+func (d Device) DisplayMode() (bool, error) {
+	if d.b == nil {
+		return false, errInvalidHandle
+	}
+	return d.b.DisplayMode()
+}
+
+// DisplayActive returns whether a display is initialized on the device, i.e.
+// whether memory is allocated on the device for display. A display can be
+// active even when no monitor is physically attached.
+func (d Device) DisplayActive() (bool, error) {
+	if d.b == nil {
+		return false, errInvalidHandle
+	}
+	return d.b.DisplayActive()
+}
+
+// CudaComputeCapability returns the major and minor CUDA compute capability of
+// the device.
+func (d Device) CudaComputeCapability() (int, int, error) {
+	if d.b == nil {
+		return 0, 0, errInvalidHandle
+	}
+	return d.b.CudaComputeCapability()
+}
+
+// TemperatureThreshold returns the temperature threshold of the given type for
+// this GPU in Celsius.
+func (d Device) TemperatureThreshold(threshold TemperatureThreshold) (uint, error) {
+	if d.b == nil {
+		return 0, errInvalidHandle
+	}
+	return d.b.TemperatureThreshold(threshold)
+}
+
+// EncoderCapacity returns the current capacity of the device's encoder of the
+// given type, in macroblocks per second.
+func (d Device) EncoderCapacity(queryType EncoderType) (uint, error) {
+	if d.b == nil {
+		return 0, errInvalidHandle
+	}
+	return d.b.EncoderCapacity(queryType)
+}
+
+// EncoderStats returns the number of active encoder sessions, the trailing
+// average FPS of all active sessions and the average encode latency in
+// microseconds.
+func (d Device) EncoderStats() (uint, uint, uint, error) {
+	if d.b == nil {
+		return 0, 0, 0, errInvalidHandle
+	}
+	return d.b.EncoderStats()
+}
+
+// EncoderSessions returns information about the active encoder sessions on
+// the device.
+func (d Device) EncoderSessions() ([]EncoderSession, error) {
+	if d.b == nil {
+		return nil, errInvalidHandle
+	}
+	return d.b.EncoderSessions()
+}
+
+// UnitCount returns the number of S-class units on the system.
+func UnitCount() (uint, error) {
+	return backend.UnitCount()
+}
+
+// Unit is the handle for an S-class unit.
+// This handle is obtained by calling UnitHandleByIndex().
+type Unit struct {
+	b UnitBackend
+	// parent is the Backend that created the handle.
+	parent Backend
+}
+
+// UnitHandleByIndex returns the unit handle for a particular index.
+// The indices range from 0 to UnitCount()-1. The order in which NVML
+// enumerates units has no guarantees of consistency between reboots.
+func UnitHandleByIndex(idx uint) (Unit, error) {
+	unit, err := backend.UnitHandleByIndex(idx)
+	return Unit{unit, backend}, err
+}
+
+// Info returns the static information associated with the unit.
+func (u Unit) Info() (UnitInfo, error) {
+	if u.b == nil {
+		return UnitInfo{}, errInvalidHandle
+	}
+	return u.b.Info()
+}
+
+// PsuInfo returns the state and readings of the unit's power supply.
+func (u Unit) PsuInfo() (PsuInfo, error) {
+	if u.b == nil {
+		return PsuInfo{}, errInvalidHandle
+	}
+	return u.b.PsuInfo()
+}
+
+// FanSpeeds returns the speed and state of each fan in the unit.
+func (u Unit) FanSpeeds() ([]UnitFan, error) {
+	if u.b == nil {
+		return nil, errInvalidHandle
+	}
+	return u.b.FanSpeeds()
+}
+
+// Temperature returns the temperature reading of the given type for the unit
+// in Celsius. Not every unit provides every type of reading.
+func (u Unit) Temperature(temperatureType UnitTemperatureType) (uint, error) {
+	if u.b == nil {
+		return 0, errInvalidHandle
+	}
+	return u.b.Temperature(temperatureType)
+}
+
+// LedState returns the color of the unit's LED and, if it is amber, the cause.
+func (u Unit) LedState() (LedState, error) {
+	if u.b == nil {
+		return LedState{}, errInvalidHandle
+	}
+	return u.b.LedState()
+}
+
+// Devices returns the handles of the devices attached to the unit.
+func (u Unit) Devices() ([]Device, error) {
+	if u.b == nil {
+		return nil, errInvalidHandle
+	}
+	devs, err := u.b.Devices()
+	if err != nil {
+		return nil, err
+	}
+	devices := make([]Device, len(devs))
+	for i := range devs {
+		devices[i] = Device{devs[i], u.parent}
+	}
+	return devices, nil
+}
+
+// SupportedVgpus returns the vGPU types supported by the device.
+func (d Device) SupportedVgpus() ([]VgpuType, error) {
+	if d.b == nil {
+		return nil, errInvalidHandle
+	}
+	return d.b.SupportedVgpus()
+}
+
+// CreatableVgpus returns the vGPU types that can currently be created on the
+// device. This may differ over time, e.g. if the device only allows one vGPU
+// type to run at a time, only the type that is already running is creatable.
+func (d Device) CreatableVgpus() ([]VgpuType, error) {
+	if d.b == nil {
+		return nil, errInvalidHandle
+	}
+	return d.b.CreatableVgpus()
+}
+
+// Class returns the class of the vGPU type, e.g. "Compute" or "Quadro".
+func (t VgpuType) Class() (string, error) {
+	return backend.VgpuType(t).Class()
+}
+
+// Name returns the name of the vGPU type, e.g. "GRID M60-2Q".
+func (t VgpuType) Name() (string, error) {
+	return backend.VgpuType(t).Name()
+}
+
+// DeviceID returns the PCI device ID and subsystem ID of the vGPU type. Each
+// value contains the vendor ID in the same 32 bit value.
+func (t VgpuType) DeviceID() (uint64, uint64, error) {
+	return backend.VgpuType(t).DeviceID()
+}
+
+// FramebufferSize returns the framebuffer size of the vGPU type in bytes.
+func (t VgpuType) FramebufferSize() (uint64, error) {
+	return backend.VgpuType(t).FramebufferSize()
+}
+
+// NumDisplayHeads returns the number of display heads supported by the vGPU
+// type.
+func (t VgpuType) NumDisplayHeads() (uint, error) {
+	return backend.VgpuType(t).NumDisplayHeads()
+}
+
+// Resolution returns the maximum resolution (width and height in pixels)
+// supported by the display head with the given zero-based index.
+func (t VgpuType) Resolution(displayIndex uint) (uint, uint, error) {
+	return backend.VgpuType(t).Resolution(displayIndex)
+}
+
+// License returns the licenses required to run the vGPU type in the form
+// "<license name>,<version>", e.g. "GRID-Virtual-PC,2.0". Multiple licenses
+// are separated by semicolons.
+func (t VgpuType) License() (string, error) {
+	return backend.VgpuType(t).License()
+}
+
+// FrameRateLimit returns the static frame rate limit of the vGPU type.
+// An error is returned if the frame rate limiter is turned off for the type.
+func (t VgpuType) FrameRateLimit() (uint, error) {
+	return backend.VgpuType(t).FrameRateLimit()
+}
+
+// MaxInstances returns the maximum number of instances of the vGPU type that
+// can be created on the device.
+func (t VgpuType) MaxInstances(d Device) (uint, error) {
+	if d.b == nil {
+		return 0, errInvalidHandle
+	}
+	return d.b.VgpuTypeMaxInstances(t)
+}
+
+// Info returns a description of the vGPU type on the device. The type is
+// looked up in the backend that created d.
+func (t VgpuType) Info(d Device) (VgpuTypeInfo, error) {
+	if d.b == nil {
+		return VgpuTypeInfo{}, errInvalidHandle
+	}
+	vt := d.parent.VgpuType(t)
+	info := VgpuTypeInfo{Type: t}
+	var err error
+	if info.Class, err = vt.Class(); err != nil {
+		return VgpuTypeInfo{}, err
+	}
+	if info.Name, err = vt.Name(); err != nil {
+		return VgpuTypeInfo{}, err
+	}
+	if info.DeviceID, info.SubsystemID, err = vt.DeviceID(); err != nil {
+		return VgpuTypeInfo{}, err
+	}
+	if info.FramebufferSize, err = vt.FramebufferSize(); err != nil {
+		return VgpuTypeInfo{}, err
+	}
+	heads, err := vt.NumDisplayHeads()
+	if err != nil {
+		return VgpuTypeInfo{}, err
+	}
+	info.MaxResolutions = make([]Resolution, heads)
+	for i := range info.MaxResolutions {
+		res := &info.MaxResolutions[i]
+		if res.Width, res.Height, err = vt.Resolution(uint(i)); err != nil {
+			return VgpuTypeInfo{}, err
+		}
+	}
+	if info.License, err = vt.License(); err != nil {
+		return VgpuTypeInfo{}, err
+	}
+	// ErrNotSupported means that the frame rate limiter is turned off for
+	// this type, which is reported as a limit of 0.
+	if info.FrameRateLimit, err = vt.FrameRateLimit(); err != nil && err != ErrNotSupported {
+		return VgpuTypeInfo{}, err
+	}
+	if info.MaxInstances, err = d.b.VgpuTypeMaxInstances(t); err != nil {
+		return VgpuTypeInfo{}, err
+	}
+	return info, nil
+}
+
+// ActiveVgpus returns the vGPU instances currently running on the device.
+func (d Device) ActiveVgpus() ([]VgpuInstance, error) {
+	if d.b == nil {
+		return nil, errInvalidHandle
+	}
+	return d.b.ActiveVgpus()
+}
+
+// VMID returns the ID of the VM the vGPU instance is assigned to. The format
+// of the ID varies by platform and is indicated by the returned VMIDType.
+func (v VgpuInstance) VMID() (string, VMIDType, error) {
+	return backend.VgpuInstance(v).VMID()
+}
+
+// UUID returns the globally unique UUID associated with the vGPU instance.
+func (v VgpuInstance) UUID() (string, error) {
+	return backend.VgpuInstance(v).UUID()
+}
+
+// VMDriverVersion returns the version of the NVIDIA driver installed in the VM
+// the vGPU instance is assigned to. It is "Unknown" if no driver is loaded in
+// the VM yet.
+func (v VgpuInstance) VMDriverVersion() (string, error) {
+	return backend.VgpuInstance(v).VMDriverVersion()
+}
+
+// FramebufferUsage returns the amount of framebuffer memory (in bytes)
+// currently used by the VM the vGPU instance is assigned to.
+func (v VgpuInstance) FramebufferUsage() (uint64, error) {
+	return backend.VgpuInstance(v).FramebufferUsage()
+}
+
+// LicenseStatus returns whether the vGPU instance is currently licensed.
+func (v VgpuInstance) LicenseStatus() (bool, error) {
+	return backend.VgpuInstance(v).LicenseStatus()
+}
+
+// Type returns the vGPU type of the vGPU instance.
+func (v VgpuInstance) Type() (VgpuType, error) {
+	return backend.VgpuInstance(v).Type()
+}
+
+// FrameRateLimit returns the frame rate limit set for the vGPU instance.
+// An error is returned if the frame rate limiter is turned off.
+func (v VgpuInstance) FrameRateLimit() (uint, error) {
+	return backend.VgpuInstance(v).FrameRateLimit()
+}
+
+// EncoderCapacity returns the encoder capacity of the vGPU instance, in
+// macroblocks per second.
+func (v VgpuInstance) EncoderCapacity() (uint, error) {
+	return backend.VgpuInstance(v).EncoderCapacity()
+}
+
+// EncoderStats returns the number of active encoder sessions, the trailing
+// average FPS of all active sessions and the average encode latency in
+// microseconds of the vGPU instance.
+func (v VgpuInstance) EncoderStats() (uint, uint, uint, error) {
+	return backend.VgpuInstance(v).EncoderStats()
+}
+
+// EncoderSessions returns information about the active encoder sessions on
+// the vGPU instance.
+func (v VgpuInstance) EncoderSessions() ([]EncoderSession, error) {
+	return backend.VgpuInstance(v).EncoderSessions()
+}
+
+// VgpuUtilization returns the SM, framebuffer, encoder and decoder utilization
+// of each vGPU instance running on the device, using the samples collected in
+// the last `since` duration. There is one sample per vGPU instance.
+func (d Device) VgpuUtilization(since time.Duration) ([]VgpuUtilizationSample, error) {
+	if d.b == nil {
+		return nil, errInvalidHandle
+	}
+	return d.b.VgpuUtilization(since)
+}
+
+// VgpuProcessUtilization returns the SM, framebuffer, encoder and decoder
+// utilization of the processes running in vGPU instances on the device, using
+// the samples collected in the last `since` duration. Only processes with
+// non-zero utilization are included.
+func (d Device) VgpuProcessUtilization(since time.Duration) ([]VgpuProcessUtilizationSample, error) {
+	if d.b == nil {
+		return nil, errInvalidHandle
+	}
+	return d.b.VgpuProcessUtilization(since)
+}
+
+// GridLicensableFeatures returns whether the system supports GRID software
+// licensing and, if it does, the licensable features and their current
+// license state.
+func (d Device) GridLicensableFeatures() (bool, []GridLicensableFeature, error) {
+	if d.b == nil {
+		return false, nil, errInvalidHandle
+	}
+	return d.b.GridLicensableFeatures()
+}
+
+// PciInfo returns the PCI attributes of the device.
+func (d Device) PciInfo() (PciInfo, error) {
+	if d.b == nil {
+		return PciInfo{}, errInvalidHandle
+	}
+	return d.b.PciInfo()
+}
+
+// QueryDrainState returns whether the GPU at the given PCI address is
+// draining, i.e. no longer accepting new incoming requests.
+// Only available on Linux.
+func QueryDrainState(pci PciInfo) (EnableState, error) {
+	return backend.QueryDrainState(pci)
+}
+
+// ModifyDrainState puts the GPU at the given PCI address into or out of the
+// draining state. A draining GPU no longer accepts new incoming requests and
+// is not visible to new NVML processes.
+// Persistence mode must be disabled for the GPU before calling this.
+// Requires root. Only available on Linux.
+func ModifyDrainState(pci PciInfo, state EnableState) error {
+	return backend.ModifyDrainState(pci, state)
+}
+
+// RemoveGPU removes the GPU at the given PCI address from the view of both
+// NVML and the kernel driver, provided no other processes are attached to it.
+// The GPU should have been put into the draining state by ModifyDrainState()
+// first.
+//
+// This changes the enumeration of devices: DeviceCount() and
+// DeviceHandleByIndex() reflect the removal, and handles for devices after the
+// removed one are no longer valid and must be re-established.
+// Requires root. Only available on Linux.
+func RemoveGPU(pci PciInfo) error {
+	return backend.RemoveGPU(pci)
+}
+
+// DiscoverGPUs asks the OS and the kernel driver to rediscover previously
+// removed GPUs in the portion of the PCI tree given by the Domain, Bus and
+// Device fields of pci. If all of them are zero the entire tree is searched.
+//
+// Newly discovered GPUs are initialized and have their ECC scrubbed, which may
+// take several seconds per GPU. Afterwards the enumeration of devices may have
+// changed and no previously obtained handle is guaranteed to be valid; use
+// DeviceCount() and DeviceHandleByIndex() or DeviceHandleByPciBusID() to
+// obtain new ones.
+// Requires root. Only available on Linux.
+func DiscoverGPUs(pci PciInfo) error {
+	return backend.DiscoverGPUs(pci)
+}
+
+// CurrPcieLinkGeneration returns the current PCIe link generation of the
+// device. It may be lower than MaxPcieLinkGeneration() while the device is
+// idle due to power saving.
+func (d Device) CurrPcieLinkGeneration() (uint, error) {
+	if d.b == nil {
+		return 0, errInvalidHandle
+	}
+	return d.b.CurrPcieLinkGeneration()
+}
+
+// MaxPcieLinkGeneration returns the maximum PCIe link generation possible
+// with this device and system, e.g. 2 for a Gen3 device in a Gen2 system.
+func (d Device) MaxPcieLinkGeneration() (uint, error) {
+	if d.b == nil {
+		return 0, errInvalidHandle
+	}
+	return d.b.MaxPcieLinkGeneration()
+}
+
+// CurrPcieLinkWidth returns the current PCIe link width (number of lanes) of
+// the device.
+func (d Device) CurrPcieLinkWidth() (uint, error) {
+	if d.b == nil {
+		return 0, errInvalidHandle
+	}
+	return d.b.CurrPcieLinkWidth()
+}
+
+// MaxPcieLinkWidth returns the maximum PCIe link width (number of lanes)
+// possible with this device and system.
+func (d Device) MaxPcieLinkWidth() (uint, error) {
+	if d.b == nil {
+		return 0, errInvalidHandle
+	}
+	return d.b.MaxPcieLinkWidth()
+}
+
+// PcieReplayCounter returns the PCIe replay counter of the device. A growing
+// counter indicates PCIe link errors.
+func (d Device) PcieReplayCounter() (uint, error) {
+	if d.b == nil {
+		return 0, errInvalidHandle
+	}
+	return d.b.PcieReplayCounter()
+}
+
+// PcieThroughput returns the PCIe throughput of the device in KB/s for the
+// given counter. The throughput is measured over a 20ms interval.
+func (d Device) PcieThroughput(counter PcieUtilCounter) (uint, error) {
+	if d.b == nil {
+		return 0, errInvalidHandle
+	}
+	return d.b.PcieThroughput(counter)
+}
+
+// DriverModel returns the current and pending driver model of the device.
+// The pending model is the one that will be in effect after the next reboot.
+// Only available on Windows.
+func (d Device) DriverModel() (DriverModel, DriverModel, error) {
+	if d.b == nil {
+		return 0, 0, errInvalidHandle
+	}
+	return d.b.DriverModel()
+}
+
+// GPUOperationMode returns the current and pending GPU operation mode (GOM)
+// of the device. The pending mode is the one that will be in effect after the
+// next reboot.
+func (d Device) GPUOperationMode() (GPUOperationMode, GPUOperationMode, error) {
+	if d.b == nil {
+		return 0, 0, errInvalidHandle
+	}
+	return d.b.GPUOperationMode()
+}
+
+// VirtualizationMode returns the virtualization mode of the device, i.e.
+// whether it is used bare-metal, passed through to a VM or used for vGPU.
+func (d Device) VirtualizationMode() (VirtualizationMode, error) {
+	if d.b == nil {
+		return VirtualizationModeNone, errInvalidHandle
+	}
+	return d.b.VirtualizationMode()
+}
+
+// ComputeRunningProcesses returns the compute processes (e.g. CUDA
+// applications) that have a context on the device.
+func (d Device) ComputeRunningProcesses() ([]ProcessInfo, error) {
+	if d.b == nil {
+		return nil, errInvalidHandle
+	}
+	return d.b.ComputeRunningProcesses()
+}
+
+// GraphicsRunningProcesses returns the graphics processes (e.g. OpenGL
+// applications) that have a context on the device.
+func (d Device) GraphicsRunningProcesses() ([]ProcessInfo, error) {
+	if d.b == nil {
+		return nil, errInvalidHandle
+	}
+	return d.b.GraphicsRunningProcesses()
+}
+
+// SetPersistenceMode enables or disables persistence mode for the device.
+// When enabled the driver remains loaded even when no active clients exist.
+// Requires root. Only available on Linux.
+// ErrNoPermission and ErrNotSupported are returned if the caller isn't
+// allowed to change the mode or the device doesn't support it, respectively.
+func (d Device) SetPersistenceMode(enabled bool) error {
+	if d.b == nil {
+		return errInvalidHandle
+	}
+	return d.b.SetPersistenceMode(enabled)
+}
+
+// SetComputeMode sets the compute mode of the device.
+// Requires root. The mode does not persist across reboots.
+// ErrNoPermission and ErrNotSupported are returned if the caller isn't
+// allowed to change the mode or the device doesn't support it, respectively.
+func (d Device) SetComputeMode(mode ComputeMode) error {
+	if d.b == nil {
+		return errInvalidHandle
+	}
+	return d.b.SetComputeMode(mode)
+}
+
+// PowerLimit returns the power management limit of the device in milliwatts.
+// If the device's total power draw reaches this limit the power management
+// algorithm kicks in.
+func (d Device) PowerLimit() (uint, error) {
+	if d.b == nil {
+		return 0, errInvalidHandle
+	}
+	return d.b.PowerLimit()
+}
+
+// DefaultPowerLimit returns the power management limit the device boots with,
+// in milliwatts.
+func (d Device) DefaultPowerLimit() (uint, error) {
+	if d.b == nil {
+		return 0, errInvalidHandle
+	}
+	return d.b.DefaultPowerLimit()
+}
+
+// PowerLimitConstraints returns the minimum and maximum power management
+// limit of the device in milliwatts.
+func (d Device) PowerLimitConstraints() (uint, uint, error) {
+	if d.b == nil {
+		return 0, 0, errInvalidHandle
+	}
+	return d.b.PowerLimitConstraints()
+}
+
+// SetPowerLimit sets the power management limit of the device in milliwatts.
+// The limit is checked against PowerLimitConstraints() first and a
+// *PowerLimitRangeError is returned if it is out of bounds.
+// Requires root. The limit does not persist across reboots or driver unloads.
+func (d Device) SetPowerLimit(milliwatts uint) error {
+	if d.b == nil {
+		return errInvalidHandle
+	}
+	min, max, err := d.b.PowerLimitConstraints()
+	if err != nil {
+		return err
+	}
+	if milliwatts < min || milliwatts > max {
+		return &PowerLimitRangeError{Limit: milliwatts, Min: min, Max: max}
+	}
+	return d.b.SetPowerLimit(milliwatts)
+}
+
+// ApplicationsClock returns the clock in MHz that applications will be
+// running at for the given clock domain. The device may run at a lower clock
+// when throttled.
+func (d Device) ApplicationsClock(clockType ClockType) (uint, error) {
+	if d.b == nil {
+		return 0, errInvalidHandle
+	}
+	return d.b.ApplicationsClock(clockType)
+}
+
+// DefaultApplicationsClock returns the default applications clock in MHz for
+// the given clock domain, i.e. the one the device boots with.
+func (d Device) DefaultApplicationsClock(clockType ClockType) (uint, error) {
+	if d.b == nil {
+		return 0, errInvalidHandle
+	}
+	return d.b.DefaultApplicationsClock(clockType)
+}
+
+// SupportedMemoryClocks returns the memory clocks in MHz that can be passed
+// to SetApplicationsClocks().
+func (d Device) SupportedMemoryClocks() ([]uint, error) {
+	if d.b == nil {
+		return nil, errInvalidHandle
+	}
+	return d.b.SupportedMemoryClocks()
+}
+
+// SupportedGraphicsClocks returns the graphics clocks in MHz that can be
+// passed to SetApplicationsClocks() along with the given memory clock.
+func (d Device) SupportedGraphicsClocks(memoryClockMHz uint) ([]uint, error) {
+	if d.b == nil {
+		return nil, errInvalidHandle
+	}
+	return d.b.SupportedGraphicsClocks(memoryClockMHz)
+}
+
+// SetApplicationsClocks sets the clocks in MHz that applications will lock to.
+// The pair is checked against SupportedMemoryClocks() and
+// SupportedGraphicsClocks() first and an *UnsupportedClocksError is returned
+// if the device doesn't support it.
+// On Pascal and newer this also disables auto boosting of clocks.
+// Requires root. The clocks are reset after a reboot or driver reload.
+func (d Device) SetApplicationsClocks(memoryClockMHz, graphicsClockMHz uint) error {
+	if d.b == nil {
+		return errInvalidHandle
+	}
+	memoryClocks, err := d.b.SupportedMemoryClocks()
+	if err != nil {
+		return err
+	}
+	if !containsClock(memoryClocks, memoryClockMHz) {
+		return &UnsupportedClocksError{MemoryClock: memoryClockMHz, GraphicsClock: graphicsClockMHz}
+	}
+	graphicsClocks, err := d.b.SupportedGraphicsClocks(memoryClockMHz)
+	if err != nil {
+		return err
+	}
+	if !containsClock(graphicsClocks, graphicsClockMHz) {
+		return &UnsupportedClocksError{MemoryClock: memoryClockMHz, GraphicsClock: graphicsClockMHz}
+	}
+	return d.b.SetApplicationsClocks(memoryClockMHz, graphicsClockMHz)
+}
+
+func containsClock(clocks []uint, clock uint) bool {
+	for _, c := range clocks {
+		if c == clock {
+			return true
+		}
+	}
+	return false
+}
+
+// ResetApplicationsClocks resets the applications clocks to their default
+// values. On Pascal and newer this also restores auto boosting of clocks.
+// Requires root.
+func (d Device) ResetApplicationsClocks() error {
+	if d.b == nil {
+		return errInvalidHandle
+	}
+	return d.b.ResetApplicationsClocks()
+}
+
+// AutoBoostedClocksEnabled returns whether auto boosted clocks are currently
+// enabled and whether they are enabled by default, i.e. when no compute
+// processes are running.
+func (d Device) AutoBoostedClocksEnabled() (bool, bool, error) {
+	if d.b == nil {
+		return false, false, errInvalidHandle
+	}
+	return d.b.AutoBoostedClocksEnabled()
+}
+
+// SetAutoBoostedClocksEnabled enables or disables auto boosted clocks for the
+// calling process. The setting reverts to the default once no compute
+// processes are running.
+// On Pascal and newer auto boosting is controlled through
+// SetApplicationsClocks() and ResetApplicationsClocks() instead.
+func (d Device) SetAutoBoostedClocksEnabled(enabled bool) error {
+	if d.b == nil {
+		return errInvalidHandle
+	}
+	return d.b.SetAutoBoostedClocksEnabled(enabled)
+}
+
+// SetDefaultAutoBoostedClocksEnabled sets whether auto boosted clocks are
+// enabled by default, i.e. when no compute processes are running.
+// Requires root.
+func (d Device) SetDefaultAutoBoostedClocksEnabled(enabled bool) error {
+	if d.b == nil {
+		return errInvalidHandle
+	}
+	return d.b.SetDefaultAutoBoostedClocksEnabled(enabled)
+}
+
+// EccMode returns whether ECC is currently enabled on the device and whether
+// it will be enabled after the next reboot.
+func (d Device) EccMode() (bool, bool, error) {
+	if d.b == nil {
+		return false, false, errInvalidHandle
+	}
+	return d.b.EccMode()
+}
+
+// SetEccMode enables or disables ECC on the device. The change only takes
+// effect after the next reboot; the returned bool reports whether a reboot is
+// needed, i.e. whether the new mode differs from the current one.
+// Requires root.
+func (d Device) SetEccMode(enabled bool) (bool, error) {
+	if d.b == nil {
+		return false, errInvalidHandle
+	}
+	return d.b.SetEccMode(enabled)
+}
+
+// ClearEccErrorCounts resets the given ECC error counters, both detailed and
+// total, to 0. ECC must be enabled. Requires root.
+func (d Device) ClearEccErrorCounts(counterType EccCounterType) error {
+	if d.b == nil {
+		return errInvalidHandle
+	}
+	return d.b.ClearEccErrorCounts(counterType)
+}
+
+// AccountingMode returns whether per process accounting is enabled.
+func (d Device) AccountingMode() (bool, error) {
+	if d.b == nil {
+		return false, errInvalidHandle
+	}
+	return d.b.AccountingMode()
+}
+
+// SetAccountingMode enables or disables per process accounting. Disabling it
+// clears the accounting information of all processes. The setting doesn't
+// survive a driver unload, so persistence mode should be enabled as well.
+// Requires root.
+func (d Device) SetAccountingMode(enabled bool) error {
+	if d.b == nil {
+		return errInvalidHandle
+	}
+	return d.b.SetAccountingMode(enabled)
+}
+
+// ClearAccountingPids clears the accounting information of all processes
+// that have already terminated. Requires root.
+func (d Device) ClearAccountingPids() error {
+	if d.b == nil {
+		return errInvalidHandle
+	}
+	return d.b.ClearAccountingPids()
+}
+
+// APIRestriction returns whether the given API is restricted to root.
+func (d Device) APIRestriction(api RestrictedAPI) (bool, error) {
+	if d.b == nil {
+		return false, errInvalidHandle
+	}
+	return d.b.APIRestriction(api)
+}
+
+// SetAPIRestriction restricts the given API to root or lifts the restriction.
+// The setting lasts until the driver is unloaded. Requires root.
+func (d Device) SetAPIRestriction(api RestrictedAPI, restricted bool) error {
+	if d.b == nil {
+		return errInvalidHandle
+	}
+	return d.b.SetAPIRestriction(api, restricted)
+}
+
+// CurrentClocksThrottleReasons returns the reasons why the clocks of the
+// device are currently being held below their maximum. More than one reason
+// may be active at the same time.
+func (d Device) CurrentClocksThrottleReasons() (ClocksThrottleReasons, error) {
+	if d.b == nil {
+		return 0, errInvalidHandle
+	}
+	return d.b.CurrentClocksThrottleReasons()
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"errors"
+	"time"
+)
+
+// Backend is the implementation behind the package level functions, Device,
+// Unit, VgpuType and VgpuInstance. By default it calls into the dynamically
+// loaded NVML library. SetBackend() replaces it, e.g. with the in-memory fake
+// in the gonvmltest package in tests that run on machines without GPUs.
+//
+// The methods have the same semantics as the package level functions of the
+// same name. Handles are returned as DeviceBackend and UnitBackend and get
+// wrapped in a Device or Unit by the package level functions.
+type Backend interface {
+	Initialize() error
+	Shutdown() error
+	SystemDriverVersion() (string, error)
+	SystemNVMLVersion() (string, error)
+	SystemCudaDriverVersion() (int, error)
+	SystemProcessName(pid uint) (string, error)
+	DeviceCount() (uint, error)
+	DeviceHandleByIndex(idx uint) (DeviceBackend, error)
+	DeviceHandleByPciBusID(busID string) (DeviceBackend, error)
+	UnitCount() (uint, error)
+	UnitHandleByIndex(idx uint) (UnitBackend, error)
+	VgpuType(t VgpuType) VgpuTypeBackend
+	VgpuInstance(v VgpuInstance) VgpuInstanceBackend
+	QueryDrainState(pci PciInfo) (EnableState, error)
+	ModifyDrainState(pci PciInfo, state EnableState) error
+	RemoveGPU(pci PciInfo) error
+	DiscoverGPUs(pci PciInfo) error
+}
+
+// DeviceBackend implements the methods of Device for a single device. See the
+// Device methods of the same name for their semantics. The arguments of
+// SetPowerLimit and SetApplicationsClocks are validated by Device before they
+// are passed on.
+type DeviceBackend interface {
+	MinorNumber() (uint, error)
+	UUID() (string, error)
+	Name() (string, error)
+	MemoryInfo() (uint64, uint64, error)
+	UtilizationRates() (uint, uint, error)
+	PowerUsage() (uint, error)
+	Samples(samplingType SamplingType, since time.Duration) ([]Sample, error)
+	Temperature() (uint, error)
+	FanSpeed() (uint, error)
+	EncoderUtilization() (uint, uint, error)
+	DecoderUtilization() (uint, uint, error)
+	VbiosVersion() (string, error)
+	InforomImageVersion() (string, error)
+	Serial() (string, error)
+	BoardPartNumber() (string, error)
+	Brand() (BrandType, error)
+	BoardID() (uint, error)
+	MultiGPUBoard() (bool, error)
+	Index() (uint, error)
+	BridgeChipInfo() ([]BridgeChip, error)
+	ComputeMode() (ComputeMode, error)
+	PersistenceMode() (bool, error)
+	DisplayMode() (bool, error)
+	DisplayActive() (bool, error)
+	CudaComputeCapability() (int, int, error)
+	TemperatureThreshold(threshold TemperatureThreshold) (uint, error)
+	EncoderCapacity(queryType EncoderType) (uint, error)
+	EncoderStats() (uint, uint, uint, error)
+	EncoderSessions() ([]EncoderSession, error)
+	SupportedVgpus() ([]VgpuType, error)
+	CreatableVgpus() ([]VgpuType, error)
+	ActiveVgpus() ([]VgpuInstance, error)
+	VgpuUtilization(since time.Duration) ([]VgpuUtilizationSample, error)
+	VgpuProcessUtilization(since time.Duration) ([]VgpuProcessUtilizationSample, error)
+	GridLicensableFeatures() (bool, []GridLicensableFeature, error)
+	PciInfo() (PciInfo, error)
+	CurrPcieLinkGeneration() (uint, error)
+	MaxPcieLinkGeneration() (uint, error)
+	CurrPcieLinkWidth() (uint, error)
+	MaxPcieLinkWidth() (uint, error)
+	PcieReplayCounter() (uint, error)
+	PcieThroughput(counter PcieUtilCounter) (uint, error)
+	DriverModel() (DriverModel, DriverModel, error)
+	GPUOperationMode() (GPUOperationMode, GPUOperationMode, error)
+	VirtualizationMode() (VirtualizationMode, error)
+	ComputeRunningProcesses() ([]ProcessInfo, error)
+	GraphicsRunningProcesses() ([]ProcessInfo, error)
+	SetPersistenceMode(enabled bool) error
+	SetComputeMode(mode ComputeMode) error
+	PowerLimit() (uint, error)
+	DefaultPowerLimit() (uint, error)
+	PowerLimitConstraints() (uint, uint, error)
+	SetPowerLimit(milliwatts uint) error
+	ApplicationsClock(clockType ClockType) (uint, error)
+	DefaultApplicationsClock(clockType ClockType) (uint, error)
+	SupportedMemoryClocks() ([]uint, error)
+	SupportedGraphicsClocks(memoryClockMHz uint) ([]uint, error)
+	SetApplicationsClocks(memoryClockMHz, graphicsClockMHz uint) error
+	ResetApplicationsClocks() error
+	AutoBoostedClocksEnabled() (bool, bool, error)
+	SetAutoBoostedClocksEnabled(enabled bool) error
+	SetDefaultAutoBoostedClocksEnabled(enabled bool) error
+	EccMode() (bool, bool, error)
+	SetEccMode(enabled bool) (bool, error)
+	ClearEccErrorCounts(counterType EccCounterType) error
+	AccountingMode() (bool, error)
+	SetAccountingMode(enabled bool) error
+	ClearAccountingPids() error
+	APIRestriction(api RestrictedAPI) (bool, error)
+	SetAPIRestriction(api RestrictedAPI, restricted bool) error
+	CurrentClocksThrottleReasons() (ClocksThrottleReasons, error)
+	VgpuTypeMaxInstances(t VgpuType) (uint, error)
+}
+
+// UnitBackend implements the methods of Unit for a single unit. See the Unit
+// methods of the same name for their semantics.
+type UnitBackend interface {
+	Info() (UnitInfo, error)
+	PsuInfo() (PsuInfo, error)
+	FanSpeeds() ([]UnitFan, error)
+	Temperature(temperatureType UnitTemperatureType) (uint, error)
+	LedState() (LedState, error)
+	Devices() ([]DeviceBackend, error)
+}
+
+// VgpuTypeBackend implements the methods of VgpuType for a single vGPU type.
+// See the VgpuType methods of the same name for their semantics.
+type VgpuTypeBackend interface {
+	Class() (string, error)
+	Name() (string, error)
+	DeviceID() (uint64, uint64, error)
+	FramebufferSize() (uint64, error)
+	NumDisplayHeads() (uint, error)
+	Resolution(displayIndex uint) (uint, uint, error)
+	License() (string, error)
+	FrameRateLimit() (uint, error)
+}
+
+// VgpuInstanceBackend implements the methods of VgpuInstance for a single
+// vGPU instance. See the VgpuInstance methods of the same name for their
+// semantics.
+type VgpuInstanceBackend interface {
+	VMID() (string, VMIDType, error)
+	UUID() (string, error)
+	VMDriverVersion() (string, error)
+	FramebufferUsage() (uint64, error)
+	LicenseStatus() (bool, error)
+	Type() (VgpuType, error)
+	FrameRateLimit() (uint, error)
+	EncoderCapacity() (uint, error)
+	EncoderStats() (uint, uint, uint, error)
+	EncoderSessions() ([]EncoderSession, error)
+}
+
+var backend = defaultBackend()
+
+// SetBackend replaces the backend used by the package level functions and
+// returns the previous one. Device and Unit handles obtained before the call
+// keep using the backend that created them. VgpuType and VgpuInstance are
+// plain IDs, so their methods use the current backend, except for the ones
+// that take a Device, which use the backend of the Device.
+// It is not safe to call SetBackend concurrently with any other function of
+// this package.
+func SetBackend(b Backend) Backend {
+	prev := backend
+	backend = b
+	return prev
+}
+
+var errInvalidHandle = errors.New("nvml: invalid handle")
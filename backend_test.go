@@ -0,0 +1,139 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mindprince/gonvml"
+	"github.com/mindprince/gonvml/gonvmltest"
+)
+
+func TestSetBackend(t *testing.T) {
+	fake := &gonvmltest.Backend{
+		DriverVersion: "390.46",
+		Devices: []*gonvmltest.Device{
+			{Name: "Tesla P100-PCIE-16GB"},
+			{Name: "Tesla V100-SXM2-16GB", Temperature: 70, SlowdownTemperature: 85, ShutdownTemperature: 90},
+		},
+	}
+	prev := gonvml.SetBackend(fake)
+	defer gonvml.SetBackend(prev)
+
+	if got := gonvml.SetBackend(fake); got != fake {
+		t.Errorf("SetBackend() = %v, want the previous backend %v", got, fake)
+	}
+	if version, err := gonvml.SystemDriverVersion(); err != nil || version != "390.46" {
+		t.Errorf("SystemDriverVersion() = %q, %v, want 390.46", version, err)
+	}
+	if count, err := gonvml.DeviceCount(); err != nil || count != 2 {
+		t.Fatalf("DeviceCount() = %d, %v, want 2", count, err)
+	}
+	dev, err := gonvml.DeviceHandleByIndex(1)
+	if err != nil {
+		t.Fatalf("DeviceHandleByIndex(1) error: %v", err)
+	}
+	if name, err := dev.Name(); err != nil || name != "Tesla V100-SXM2-16GB" {
+		t.Errorf("Name() = %q, %v, want Tesla V100-SXM2-16GB", name, err)
+	}
+	// ThermalHeadroom() is implemented on top of the backend.
+	if slowdown, shutdown, err := dev.ThermalHeadroom(); err != nil || slowdown != 15 || shutdown != 20 {
+		t.Errorf("ThermalHeadroom() = %d, %d, %v, want 15, 20", slowdown, shutdown, err)
+	}
+	if _, err := dev.FanSpeed(); err != gonvml.ErrNotSupported {
+		t.Errorf("FanSpeed() error = %v, want ErrNotSupported", err)
+	}
+
+	// Handles keep using the backend that created them.
+	gonvml.SetBackend(&gonvmltest.Backend{})
+	if name, err := dev.Name(); err != nil || name != "Tesla V100-SXM2-16GB" {
+		t.Errorf("Name() after SetBackend() = %q, %v, want Tesla V100-SXM2-16GB", name, err)
+	}
+	if count, err := gonvml.DeviceCount(); err != nil || count != 0 {
+		t.Errorf("DeviceCount() after SetBackend() = %d, %v, want 0", count, err)
+	}
+}
+
+func TestZeroDevice(t *testing.T) {
+	var dev gonvml.Device
+	_, err := dev.Name()
+	if err == nil || !strings.HasPrefix(err.Error(), "nvml: ") {
+		t.Errorf("Name() of the zero Device error = %v, want an nvml error", err)
+	}
+}
+
+func TestAverages(t *testing.T) {
+	now := time.Now()
+	fake := &gonvmltest.Backend{
+		Devices: []*gonvmltest.Device{{
+			Samples: map[gonvml.SamplingType][]gonvml.Sample{
+				gonvml.TotalPowerSamples: {
+					{Time: now.Add(-time.Minute), Value: 300000},
+					{Time: now.Add(-2 * time.Second), Value: 100000},
+					{Time: now.Add(-time.Second), Value: 200000},
+				},
+			},
+		}},
+	}
+	prev := gonvml.SetBackend(fake)
+	defer gonvml.SetBackend(prev)
+
+	dev, err := gonvml.DeviceHandleByIndex(0)
+	if err != nil {
+		t.Fatalf("DeviceHandleByIndex(0) error: %v", err)
+	}
+	if power, err := dev.AveragePowerUsage(10 * time.Second); err != nil || power != 150000 {
+		t.Errorf("AveragePowerUsage(10s) = %d, %v, want 150000", power, err)
+	}
+	if _, err := dev.AverageGPUUtilization(10 * time.Second); err != gonvml.ErrNoSamples {
+		t.Errorf("AverageGPUUtilization(10s) without samples error = %v, want ErrNoSamples", err)
+	}
+}
+
+func TestSetterValidation(t *testing.T) {
+	fake := &gonvmltest.Backend{
+		Devices: []*gonvmltest.Device{{
+			PowerLimit:      250000,
+			MinPowerLimit:   125000,
+			MaxPowerLimit:   250000,
+			SupportedClocks: map[uint][]uint{877: {1380, 1530}},
+		}},
+	}
+	prev := gonvml.SetBackend(fake)
+	defer gonvml.SetBackend(prev)
+
+	dev, err := gonvml.DeviceHandleByIndex(0)
+	if err != nil {
+		t.Fatalf("DeviceHandleByIndex(0) error: %v", err)
+	}
+	if err, ok := dev.SetPowerLimit(300000).(*gonvml.PowerLimitRangeError); !ok || err.Min != 125000 || err.Max != 250000 {
+		t.Errorf("SetPowerLimit(300000) error = %v, want a *PowerLimitRangeError", err)
+	}
+	if err := dev.SetPowerLimit(200000); err != nil || fake.Devices[0].PowerLimit != 200000 {
+		t.Errorf("SetPowerLimit(200000) = %v, limit %d", err, fake.Devices[0].PowerLimit)
+	}
+	for _, clocks := range [][2]uint{{877, 1000}, {715, 1380}} {
+		if _, ok := dev.SetApplicationsClocks(clocks[0], clocks[1]).(*gonvml.UnsupportedClocksError); !ok {
+			t.Errorf("SetApplicationsClocks(%d, %d) succeeded, want an *UnsupportedClocksError", clocks[0], clocks[1])
+		}
+	}
+	if err := dev.SetApplicationsClocks(877, 1530); err != nil || fake.Devices[0].GraphicsClock != 1530 {
+		t.Errorf("SetApplicationsClocks(877, 1530) = %v, graphics clock %d", err, fake.Devices[0].GraphicsClock)
+	}
+}
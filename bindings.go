@@ -143,382 +143,2657 @@ nvmlReturn_t nvmlDeviceGetDecoderUtilization(nvmlDevice_t device, unsigned int*
   return nvmlDeviceGetDecoderUtilizationFunc(device, utilization, samplingPeriodUs);
 }
 
-nvmlReturn_t (*nvmlDeviceGetSamplesFunc)(nvmlDevice_t device, nvmlSamplingType_t type, unsigned long long lastSeenTimeStamp, nvmlValueType_t *sampleValType, unsigned int *sampleCount, nvmlSample_t *samples);
+nvmlReturn_t (*nvmlDeviceGetVbiosVersionFunc)(nvmlDevice_t device, char *version, unsigned int length);
+nvmlReturn_t nvmlDeviceGetVbiosVersion(nvmlDevice_t device, char *version, unsigned int length) {
+  if (nvmlDeviceGetVbiosVersionFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetVbiosVersionFunc(device, version, length);
+}
 
-// Loads the "libnvidia-ml.so.1" shared library.
-// Loads all symbols needed and initializes NVML.
-// Call this before calling any other methods.
-nvmlReturn_t nvmlInit_dl(void) {
-  nvmlHandle = dlopen("libnvidia-ml.so.1", RTLD_LAZY);
-  if (nvmlHandle == NULL) {
-    return NVML_ERROR_LIBRARY_NOT_FOUND;
+nvmlReturn_t (*nvmlDeviceGetSerialFunc)(nvmlDevice_t device, char *serial, unsigned int length);
+nvmlReturn_t nvmlDeviceGetSerial(nvmlDevice_t device, char *serial, unsigned int length) {
+  if (nvmlDeviceGetSerialFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlInitFunc = dlsym(nvmlHandle, "nvmlInit_v2");
-  if (nvmlInitFunc == NULL) {
+  return nvmlDeviceGetSerialFunc(device, serial, length);
+}
+
+nvmlReturn_t (*nvmlDeviceGetBoardPartNumberFunc)(nvmlDevice_t device, char *partNumber, unsigned int length);
+nvmlReturn_t nvmlDeviceGetBoardPartNumber(nvmlDevice_t device, char *partNumber, unsigned int length) {
+  if (nvmlDeviceGetBoardPartNumberFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlShutdownFunc = dlsym(nvmlHandle, "nvmlShutdown");
-  if (nvmlShutdownFunc == NULL) {
+  return nvmlDeviceGetBoardPartNumberFunc(device, partNumber, length);
+}
+
+nvmlReturn_t (*nvmlDeviceGetBrandFunc)(nvmlDevice_t device, nvmlBrandType_t *type);
+nvmlReturn_t nvmlDeviceGetBrand(nvmlDevice_t device, nvmlBrandType_t *type) {
+  if (nvmlDeviceGetBrandFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlErrorStringFunc = dlsym(nvmlHandle, "nvmlErrorString");
-  if (nvmlErrorStringFunc == NULL) {
+  return nvmlDeviceGetBrandFunc(device, type);
+}
+
+nvmlReturn_t (*nvmlDeviceGetBoardIdFunc)(nvmlDevice_t device, unsigned int *boardId);
+nvmlReturn_t nvmlDeviceGetBoardId(nvmlDevice_t device, unsigned int *boardId) {
+  if (nvmlDeviceGetBoardIdFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlSystemGetDriverVersionFunc = dlsym(nvmlHandle, "nvmlSystemGetDriverVersion");
-  if (nvmlSystemGetDriverVersionFunc == NULL) {
+  return nvmlDeviceGetBoardIdFunc(device, boardId);
+}
+
+nvmlReturn_t (*nvmlDeviceGetMultiGpuBoardFunc)(nvmlDevice_t device, unsigned int *multiGpuBool);
+nvmlReturn_t nvmlDeviceGetMultiGpuBoard(nvmlDevice_t device, unsigned int *multiGpuBool) {
+  if (nvmlDeviceGetMultiGpuBoardFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetCountFunc = dlsym(nvmlHandle, "nvmlDeviceGetCount_v2");
-  if (nvmlDeviceGetCountFunc == NULL) {
+  return nvmlDeviceGetMultiGpuBoardFunc(device, multiGpuBool);
+}
+
+nvmlReturn_t (*nvmlDeviceGetIndexFunc)(nvmlDevice_t device, unsigned int *index);
+nvmlReturn_t nvmlDeviceGetIndex(nvmlDevice_t device, unsigned int *index) {
+  if (nvmlDeviceGetIndexFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetHandleByIndexFunc = dlsym(nvmlHandle, "nvmlDeviceGetHandleByIndex_v2");
-  if (nvmlDeviceGetHandleByIndexFunc == NULL) {
+  return nvmlDeviceGetIndexFunc(device, index);
+}
+
+nvmlReturn_t (*nvmlDeviceGetBridgeChipInfoFunc)(nvmlDevice_t device, nvmlBridgeChipHierarchy_t *bridgeHierarchy);
+nvmlReturn_t nvmlDeviceGetBridgeChipInfo(nvmlDevice_t device, nvmlBridgeChipHierarchy_t *bridgeHierarchy) {
+  if (nvmlDeviceGetBridgeChipInfoFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetMinorNumberFunc = dlsym(nvmlHandle, "nvmlDeviceGetMinorNumber");
-  if (nvmlDeviceGetMinorNumberFunc == NULL) {
+  return nvmlDeviceGetBridgeChipInfoFunc(device, bridgeHierarchy);
+}
+
+nvmlReturn_t (*nvmlDeviceGetComputeModeFunc)(nvmlDevice_t device, nvmlComputeMode_t *mode);
+nvmlReturn_t nvmlDeviceGetComputeMode(nvmlDevice_t device, nvmlComputeMode_t *mode) {
+  if (nvmlDeviceGetComputeModeFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetUUIDFunc = dlsym(nvmlHandle, "nvmlDeviceGetUUID");
-  if (nvmlDeviceGetUUIDFunc == NULL) {
+  return nvmlDeviceGetComputeModeFunc(device, mode);
+}
+
+nvmlReturn_t (*nvmlDeviceGetPersistenceModeFunc)(nvmlDevice_t device, nvmlEnableState_t *mode);
+nvmlReturn_t nvmlDeviceGetPersistenceMode(nvmlDevice_t device, nvmlEnableState_t *mode) {
+  if (nvmlDeviceGetPersistenceModeFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetNameFunc = dlsym(nvmlHandle, "nvmlDeviceGetName");
-  if (nvmlDeviceGetNameFunc == NULL) {
+  return nvmlDeviceGetPersistenceModeFunc(device, mode);
+}
+
+nvmlReturn_t (*nvmlDeviceGetDisplayModeFunc)(nvmlDevice_t device, nvmlEnableState_t *display);
+nvmlReturn_t nvmlDeviceGetDisplayMode(nvmlDevice_t device, nvmlEnableState_t *display) {
+  if (nvmlDeviceGetDisplayModeFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetMemoryInfoFunc = dlsym(nvmlHandle, "nvmlDeviceGetMemoryInfo");
-  if (nvmlDeviceGetMemoryInfoFunc == NULL) {
+  return nvmlDeviceGetDisplayModeFunc(device, display);
+}
+
+nvmlReturn_t (*nvmlDeviceGetDisplayActiveFunc)(nvmlDevice_t device, nvmlEnableState_t *isActive);
+nvmlReturn_t nvmlDeviceGetDisplayActive(nvmlDevice_t device, nvmlEnableState_t *isActive) {
+  if (nvmlDeviceGetDisplayActiveFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetUtilizationRatesFunc = dlsym(nvmlHandle, "nvmlDeviceGetUtilizationRates");
-  if (nvmlDeviceGetUtilizationRatesFunc == NULL) {
+  return nvmlDeviceGetDisplayActiveFunc(device, isActive);
+}
+
+nvmlReturn_t (*nvmlSystemGetNVMLVersionFunc)(char *version, unsigned int length);
+nvmlReturn_t nvmlSystemGetNVMLVersion(char *version, unsigned int length) {
+  if (nvmlSystemGetNVMLVersionFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetPowerUsageFunc = dlsym(nvmlHandle, "nvmlDeviceGetPowerUsage");
-  if (nvmlDeviceGetPowerUsageFunc == NULL) {
+  return nvmlSystemGetNVMLVersionFunc(version, length);
+}
+
+nvmlReturn_t (*nvmlSystemGetCudaDriverVersionFunc)(int *cudaDriverVersion);
+nvmlReturn_t nvmlSystemGetCudaDriverVersion(int *cudaDriverVersion) {
+  if (nvmlSystemGetCudaDriverVersionFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetTemperatureFunc = dlsym(nvmlHandle, "nvmlDeviceGetTemperature");
-  if (nvmlDeviceGetTemperatureFunc == NULL) {
+  return nvmlSystemGetCudaDriverVersionFunc(cudaDriverVersion);
+}
+
+nvmlReturn_t (*nvmlDeviceGetCudaComputeCapabilityFunc)(nvmlDevice_t device, int *major, int *minor);
+nvmlReturn_t nvmlDeviceGetCudaComputeCapability(nvmlDevice_t device, int *major, int *minor) {
+  if (nvmlDeviceGetCudaComputeCapabilityFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetFanSpeedFunc = dlsym(nvmlHandle, "nvmlDeviceGetFanSpeed");
-  if (nvmlDeviceGetFanSpeedFunc == NULL) {
+  return nvmlDeviceGetCudaComputeCapabilityFunc(device, major, minor);
+}
+
+nvmlReturn_t (*nvmlDeviceGetTemperatureThresholdFunc)(nvmlDevice_t device, nvmlTemperatureThresholds_t thresholdType, unsigned int *temp);
+nvmlReturn_t nvmlDeviceGetTemperatureThreshold(nvmlDevice_t device, nvmlTemperatureThresholds_t thresholdType, unsigned int *temp) {
+  if (nvmlDeviceGetTemperatureThresholdFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetSamplesFunc = dlsym(nvmlHandle, "nvmlDeviceGetSamples");
-  if (nvmlDeviceGetSamplesFunc == NULL) {
+  return nvmlDeviceGetTemperatureThresholdFunc(device, thresholdType, temp);
+}
+
+nvmlReturn_t (*nvmlDeviceGetEncoderCapacityFunc)(nvmlDevice_t device, nvmlEncoderType_t encoderQueryType, unsigned int *encoderCapacity);
+nvmlReturn_t nvmlDeviceGetEncoderCapacity(nvmlDevice_t device, nvmlEncoderType_t encoderQueryType, unsigned int *encoderCapacity) {
+  if (nvmlDeviceGetEncoderCapacityFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetEncoderUtilizationFunc = dlsym(nvmlHandle, "nvmlDeviceGetEncoderUtilization");
-  if (nvmlDeviceGetEncoderUtilizationFunc == NULL) {
+  return nvmlDeviceGetEncoderCapacityFunc(device, encoderQueryType, encoderCapacity);
+}
+
+nvmlReturn_t (*nvmlDeviceGetEncoderStatsFunc)(nvmlDevice_t device, unsigned int *sessionCount, unsigned int *averageFps, unsigned int *averageLatency);
+nvmlReturn_t nvmlDeviceGetEncoderStats(nvmlDevice_t device, unsigned int *sessionCount, unsigned int *averageFps, unsigned int *averageLatency) {
+  if (nvmlDeviceGetEncoderStatsFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetDecoderUtilizationFunc = dlsym(nvmlHandle, "nvmlDeviceGetDecoderUtilization");
-  if (nvmlDeviceGetDecoderUtilizationFunc == NULL) {
+  return nvmlDeviceGetEncoderStatsFunc(device, sessionCount, averageFps, averageLatency);
+}
+
+nvmlReturn_t (*nvmlDeviceGetEncoderSessionsFunc)(nvmlDevice_t device, unsigned int *sessionCount, nvmlEncoderSessionInfo_t *sessionInfos);
+nvmlReturn_t nvmlDeviceGetEncoderSessions(nvmlDevice_t device, unsigned int *sessionCount, nvmlEncoderSessionInfo_t *sessionInfos) {
+  if (nvmlDeviceGetEncoderSessionsFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlReturn_t result = nvmlInitFunc();
-  if (result != NVML_SUCCESS) {
-    dlclose(nvmlHandle);
-    nvmlHandle = NULL;
-    return result;
+  return nvmlDeviceGetEncoderSessionsFunc(device, sessionCount, sessionInfos);
+}
+
+nvmlReturn_t (*nvmlUnitGetCountFunc)(unsigned int *unitCount);
+nvmlReturn_t nvmlUnitGetCount(unsigned int *unitCount) {
+  if (nvmlUnitGetCountFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  return NVML_SUCCESS;
+  return nvmlUnitGetCountFunc(unitCount);
 }
 
-// Shuts down NVML and decrements the reference count on the dynamically loaded
-// "libnvidia-ml.so.1" library.
-// Call this once NVML is no longer being used.
-nvmlReturn_t nvmlShutdown_dl(void) {
-  if (nvmlHandle == NULL) {
-    return NVML_SUCCESS;
+nvmlReturn_t (*nvmlUnitGetHandleByIndexFunc)(unsigned int index, nvmlUnit_t *unit);
+nvmlReturn_t nvmlUnitGetHandleByIndex(unsigned int index, nvmlUnit_t *unit) {
+  if (nvmlUnitGetHandleByIndexFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  if (nvmlShutdownFunc == NULL) {
+  return nvmlUnitGetHandleByIndexFunc(index, unit);
+}
+
+nvmlReturn_t (*nvmlUnitGetUnitInfoFunc)(nvmlUnit_t unit, nvmlUnitInfo_t *info);
+nvmlReturn_t nvmlUnitGetUnitInfo(nvmlUnit_t unit, nvmlUnitInfo_t *info) {
+  if (nvmlUnitGetUnitInfoFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlReturn_t r = nvmlShutdownFunc();
-  if (r != NVML_SUCCESS) {
-    return r;
+  return nvmlUnitGetUnitInfoFunc(unit, info);
+}
+
+nvmlReturn_t (*nvmlUnitGetLedStateFunc)(nvmlUnit_t unit, nvmlLedState_t *state);
+nvmlReturn_t nvmlUnitGetLedState(nvmlUnit_t unit, nvmlLedState_t *state) {
+  if (nvmlUnitGetLedStateFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  return (dlclose(nvmlHandle) ? NVML_ERROR_UNKNOWN : NVML_SUCCESS);
+  return nvmlUnitGetLedStateFunc(unit, state);
 }
 
-// This function is here because the API provided by NVML is not very user
-// friendly. This function can be used to get average utilization.gpu or
-// power.draw.
-//
-// `device`: The identifier of the target device.
-// `type`: Type of sampling event. Only NVML_TOTAL_POWER_SAMPLES and NVML_GPU_UTILIZATION_SAMPLES are supported.
-// `lastSeenTimeStamp`: Return average using samples with timestamp greather than this timestamp. Unix epoch in micro seconds.
-// `averageUsage`: Reference in which average is returned.
-//
-// In my experiments, I found that NVML_GPU_UTILIZATION_SAMPLES buffer stores
-// 100 samples that are uniformly spread with ~6 samples per second. So the
-// buffer stores last ~16s of data.
-// NVML_TOTAL_POWER_SAMPLES buffer stores 120 samples, but in different runs I
-// noticed them to be non-uniformly separated. Sometimes 120 samples only
-// consisted of 10s of data and sometimes they were spread over 60s.
-//
-nvmlReturn_t nvmlDeviceGetAverageUsage(nvmlDevice_t device, nvmlSamplingType_t type, unsigned long long lastSeenTimeStamp, unsigned int* averageUsage) {
-  if (nvmlHandle == NULL) {
-    return NVML_ERROR_LIBRARY_NOT_FOUND;
+nvmlReturn_t (*nvmlUnitGetPsuInfoFunc)(nvmlUnit_t unit, nvmlPSUInfo_t *psu);
+nvmlReturn_t nvmlUnitGetPsuInfo(nvmlUnit_t unit, nvmlPSUInfo_t *psu) {
+  if (nvmlUnitGetPsuInfoFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  if (nvmlDeviceGetSamplesFunc == NULL) {
+  return nvmlUnitGetPsuInfoFunc(unit, psu);
+}
+
+nvmlReturn_t (*nvmlUnitGetTemperatureFunc)(nvmlUnit_t unit, unsigned int type, unsigned int *temp);
+nvmlReturn_t nvmlUnitGetTemperature(nvmlUnit_t unit, unsigned int type, unsigned int *temp) {
+  if (nvmlUnitGetTemperatureFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlUnitGetTemperatureFunc(unit, type, temp);
+}
+
+nvmlReturn_t (*nvmlUnitGetFanSpeedInfoFunc)(nvmlUnit_t unit, nvmlUnitFanSpeeds_t *fanSpeeds);
+nvmlReturn_t nvmlUnitGetFanSpeedInfo(nvmlUnit_t unit, nvmlUnitFanSpeeds_t *fanSpeeds) {
+  if (nvmlUnitGetFanSpeedInfoFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
+  return nvmlUnitGetFanSpeedInfoFunc(unit, fanSpeeds);
+}
 
-  // We don't really use this because both the metrics we support
-  // averagePowerUsage and averageGPUUtilization are unsigned int.
-  nvmlValueType_t sampleValType;
+nvmlReturn_t (*nvmlUnitGetDevicesFunc)(nvmlUnit_t unit, unsigned int *deviceCount, nvmlDevice_t *devices);
+nvmlReturn_t nvmlUnitGetDevices(nvmlUnit_t unit, unsigned int *deviceCount, nvmlDevice_t *devices) {
+  if (nvmlUnitGetDevicesFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlUnitGetDevicesFunc(unit, deviceCount, devices);
+}
 
-  // This will be set to the number of samples that can be queried. We would
-  // need to allocate an array of this size to store the samples.
-  unsigned int sampleCount;
+nvmlReturn_t (*nvmlDeviceGetSupportedVgpusFunc)(nvmlDevice_t device, unsigned int *vgpuCount, nvmlVgpuTypeId_t *vgpuTypeIds);
+nvmlReturn_t nvmlDeviceGetSupportedVgpus(nvmlDevice_t device, unsigned int *vgpuCount, nvmlVgpuTypeId_t *vgpuTypeIds) {
+  if (nvmlDeviceGetSupportedVgpusFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetSupportedVgpusFunc(device, vgpuCount, vgpuTypeIds);
+}
 
-  // Invoking this method with `samples` set to NULL sets the sampleCount.
-  nvmlReturn_t r = nvmlDeviceGetSamplesFunc(device, type, lastSeenTimeStamp, &sampleValType, &sampleCount, NULL);
-  if (r != NVML_SUCCESS) {
-    return r;
+nvmlReturn_t (*nvmlDeviceGetCreatableVgpusFunc)(nvmlDevice_t device, unsigned int *vgpuCount, nvmlVgpuTypeId_t *vgpuTypeIds);
+nvmlReturn_t nvmlDeviceGetCreatableVgpus(nvmlDevice_t device, unsigned int *vgpuCount, nvmlVgpuTypeId_t *vgpuTypeIds) {
+  if (nvmlDeviceGetCreatableVgpusFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetCreatableVgpusFunc(device, vgpuCount, vgpuTypeIds);
+}
+
+nvmlReturn_t (*nvmlVgpuTypeGetClassFunc)(nvmlVgpuTypeId_t vgpuTypeId, char *vgpuTypeClass, unsigned int *size);
+nvmlReturn_t nvmlVgpuTypeGetClass(nvmlVgpuTypeId_t vgpuTypeId, char *vgpuTypeClass, unsigned int *size) {
+  if (nvmlVgpuTypeGetClassFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlVgpuTypeGetClassFunc(vgpuTypeId, vgpuTypeClass, size);
+}
+
+nvmlReturn_t (*nvmlVgpuTypeGetNameFunc)(nvmlVgpuTypeId_t vgpuTypeId, char *vgpuTypeName, unsigned int *size);
+nvmlReturn_t nvmlVgpuTypeGetName(nvmlVgpuTypeId_t vgpuTypeId, char *vgpuTypeName, unsigned int *size) {
+  if (nvmlVgpuTypeGetNameFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlVgpuTypeGetNameFunc(vgpuTypeId, vgpuTypeName, size);
+}
+
+nvmlReturn_t (*nvmlVgpuTypeGetDeviceIDFunc)(nvmlVgpuTypeId_t vgpuTypeId, unsigned long long *deviceID, unsigned long long *subsystemID);
+nvmlReturn_t nvmlVgpuTypeGetDeviceID(nvmlVgpuTypeId_t vgpuTypeId, unsigned long long *deviceID, unsigned long long *subsystemID) {
+  if (nvmlVgpuTypeGetDeviceIDFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlVgpuTypeGetDeviceIDFunc(vgpuTypeId, deviceID, subsystemID);
+}
+
+nvmlReturn_t (*nvmlVgpuTypeGetFramebufferSizeFunc)(nvmlVgpuTypeId_t vgpuTypeId, unsigned long long *fbSize);
+nvmlReturn_t nvmlVgpuTypeGetFramebufferSize(nvmlVgpuTypeId_t vgpuTypeId, unsigned long long *fbSize) {
+  if (nvmlVgpuTypeGetFramebufferSizeFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlVgpuTypeGetFramebufferSizeFunc(vgpuTypeId, fbSize);
+}
+
+nvmlReturn_t (*nvmlVgpuTypeGetNumDisplayHeadsFunc)(nvmlVgpuTypeId_t vgpuTypeId, unsigned int *numDisplayHeads);
+nvmlReturn_t nvmlVgpuTypeGetNumDisplayHeads(nvmlVgpuTypeId_t vgpuTypeId, unsigned int *numDisplayHeads) {
+  if (nvmlVgpuTypeGetNumDisplayHeadsFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlVgpuTypeGetNumDisplayHeadsFunc(vgpuTypeId, numDisplayHeads);
+}
+
+nvmlReturn_t (*nvmlVgpuTypeGetResolutionFunc)(nvmlVgpuTypeId_t vgpuTypeId, unsigned int displayIndex, unsigned int *xdim, unsigned int *ydim);
+nvmlReturn_t nvmlVgpuTypeGetResolution(nvmlVgpuTypeId_t vgpuTypeId, unsigned int displayIndex, unsigned int *xdim, unsigned int *ydim) {
+  if (nvmlVgpuTypeGetResolutionFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlVgpuTypeGetResolutionFunc(vgpuTypeId, displayIndex, xdim, ydim);
+}
+
+nvmlReturn_t (*nvmlVgpuTypeGetLicenseFunc)(nvmlVgpuTypeId_t vgpuTypeId, char *vgpuTypeLicenseString, unsigned int size);
+nvmlReturn_t nvmlVgpuTypeGetLicense(nvmlVgpuTypeId_t vgpuTypeId, char *vgpuTypeLicenseString, unsigned int size) {
+  if (nvmlVgpuTypeGetLicenseFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlVgpuTypeGetLicenseFunc(vgpuTypeId, vgpuTypeLicenseString, size);
+}
+
+nvmlReturn_t (*nvmlVgpuTypeGetFrameRateLimitFunc)(nvmlVgpuTypeId_t vgpuTypeId, unsigned int *frameRateLimit);
+nvmlReturn_t nvmlVgpuTypeGetFrameRateLimit(nvmlVgpuTypeId_t vgpuTypeId, unsigned int *frameRateLimit) {
+  if (nvmlVgpuTypeGetFrameRateLimitFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlVgpuTypeGetFrameRateLimitFunc(vgpuTypeId, frameRateLimit);
+}
+
+nvmlReturn_t (*nvmlVgpuTypeGetMaxInstancesFunc)(nvmlDevice_t device, nvmlVgpuTypeId_t vgpuTypeId, unsigned int *vgpuInstanceCount);
+nvmlReturn_t nvmlVgpuTypeGetMaxInstances(nvmlDevice_t device, nvmlVgpuTypeId_t vgpuTypeId, unsigned int *vgpuInstanceCount) {
+  if (nvmlVgpuTypeGetMaxInstancesFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlVgpuTypeGetMaxInstancesFunc(device, vgpuTypeId, vgpuInstanceCount);
+}
+
+nvmlReturn_t (*nvmlDeviceGetActiveVgpusFunc)(nvmlDevice_t device, unsigned int *vgpuCount, nvmlVgpuInstance_t *vgpuInstances);
+nvmlReturn_t nvmlDeviceGetActiveVgpus(nvmlDevice_t device, unsigned int *vgpuCount, nvmlVgpuInstance_t *vgpuInstances) {
+  if (nvmlDeviceGetActiveVgpusFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetActiveVgpusFunc(device, vgpuCount, vgpuInstances);
+}
+
+nvmlReturn_t (*nvmlVgpuInstanceGetVmIDFunc)(nvmlVgpuInstance_t vgpuInstance, char *vmId, unsigned int size, nvmlVgpuVmIdType_t *vmIdType);
+nvmlReturn_t nvmlVgpuInstanceGetVmID(nvmlVgpuInstance_t vgpuInstance, char *vmId, unsigned int size, nvmlVgpuVmIdType_t *vmIdType) {
+  if (nvmlVgpuInstanceGetVmIDFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlVgpuInstanceGetVmIDFunc(vgpuInstance, vmId, size, vmIdType);
+}
+
+nvmlReturn_t (*nvmlVgpuInstanceGetUUIDFunc)(nvmlVgpuInstance_t vgpuInstance, char *uuid, unsigned int size);
+nvmlReturn_t nvmlVgpuInstanceGetUUID(nvmlVgpuInstance_t vgpuInstance, char *uuid, unsigned int size) {
+  if (nvmlVgpuInstanceGetUUIDFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlVgpuInstanceGetUUIDFunc(vgpuInstance, uuid, size);
+}
+
+nvmlReturn_t (*nvmlVgpuInstanceGetVmDriverVersionFunc)(nvmlVgpuInstance_t vgpuInstance, char *version, unsigned int length);
+nvmlReturn_t nvmlVgpuInstanceGetVmDriverVersion(nvmlVgpuInstance_t vgpuInstance, char *version, unsigned int length) {
+  if (nvmlVgpuInstanceGetVmDriverVersionFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlVgpuInstanceGetVmDriverVersionFunc(vgpuInstance, version, length);
+}
+
+nvmlReturn_t (*nvmlVgpuInstanceGetFbUsageFunc)(nvmlVgpuInstance_t vgpuInstance, unsigned long long *fbUsage);
+nvmlReturn_t nvmlVgpuInstanceGetFbUsage(nvmlVgpuInstance_t vgpuInstance, unsigned long long *fbUsage) {
+  if (nvmlVgpuInstanceGetFbUsageFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlVgpuInstanceGetFbUsageFunc(vgpuInstance, fbUsage);
+}
+
+nvmlReturn_t (*nvmlVgpuInstanceGetLicenseStatusFunc)(nvmlVgpuInstance_t vgpuInstance, unsigned int *licensed);
+nvmlReturn_t nvmlVgpuInstanceGetLicenseStatus(nvmlVgpuInstance_t vgpuInstance, unsigned int *licensed) {
+  if (nvmlVgpuInstanceGetLicenseStatusFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlVgpuInstanceGetLicenseStatusFunc(vgpuInstance, licensed);
+}
+
+nvmlReturn_t (*nvmlVgpuInstanceGetTypeFunc)(nvmlVgpuInstance_t vgpuInstance, nvmlVgpuTypeId_t *vgpuTypeId);
+nvmlReturn_t nvmlVgpuInstanceGetType(nvmlVgpuInstance_t vgpuInstance, nvmlVgpuTypeId_t *vgpuTypeId) {
+  if (nvmlVgpuInstanceGetTypeFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlVgpuInstanceGetTypeFunc(vgpuInstance, vgpuTypeId);
+}
+
+nvmlReturn_t (*nvmlVgpuInstanceGetFrameRateLimitFunc)(nvmlVgpuInstance_t vgpuInstance, unsigned int *frameRateLimit);
+nvmlReturn_t nvmlVgpuInstanceGetFrameRateLimit(nvmlVgpuInstance_t vgpuInstance, unsigned int *frameRateLimit) {
+  if (nvmlVgpuInstanceGetFrameRateLimitFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlVgpuInstanceGetFrameRateLimitFunc(vgpuInstance, frameRateLimit);
+}
+
+nvmlReturn_t (*nvmlVgpuInstanceGetEncoderCapacityFunc)(nvmlVgpuInstance_t vgpuInstance, unsigned int *encoderCapacity);
+nvmlReturn_t nvmlVgpuInstanceGetEncoderCapacity(nvmlVgpuInstance_t vgpuInstance, unsigned int *encoderCapacity) {
+  if (nvmlVgpuInstanceGetEncoderCapacityFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlVgpuInstanceGetEncoderCapacityFunc(vgpuInstance, encoderCapacity);
+}
+
+nvmlReturn_t (*nvmlVgpuInstanceGetEncoderStatsFunc)(nvmlVgpuInstance_t vgpuInstance, unsigned int *sessionCount, unsigned int *averageFps, unsigned int *averageLatency);
+nvmlReturn_t nvmlVgpuInstanceGetEncoderStats(nvmlVgpuInstance_t vgpuInstance, unsigned int *sessionCount, unsigned int *averageFps, unsigned int *averageLatency) {
+  if (nvmlVgpuInstanceGetEncoderStatsFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlVgpuInstanceGetEncoderStatsFunc(vgpuInstance, sessionCount, averageFps, averageLatency);
+}
+
+nvmlReturn_t (*nvmlVgpuInstanceGetEncoderSessionsFunc)(nvmlVgpuInstance_t vgpuInstance, unsigned int *sessionCount, nvmlEncoderSessionInfo_t *sessionInfo);
+nvmlReturn_t nvmlVgpuInstanceGetEncoderSessions(nvmlVgpuInstance_t vgpuInstance, unsigned int *sessionCount, nvmlEncoderSessionInfo_t *sessionInfo) {
+  if (nvmlVgpuInstanceGetEncoderSessionsFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlVgpuInstanceGetEncoderSessionsFunc(vgpuInstance, sessionCount, sessionInfo);
+}
+
+nvmlReturn_t (*nvmlDeviceGetVgpuUtilizationFunc)(nvmlDevice_t device, unsigned long long lastSeenTimeStamp, nvmlValueType_t *sampleValType, unsigned int *vgpuInstanceSamplesCount, nvmlVgpuInstanceUtilizationSample_t *utilizationSamples);
+nvmlReturn_t nvmlDeviceGetVgpuUtilization(nvmlDevice_t device, unsigned long long lastSeenTimeStamp, nvmlValueType_t *sampleValType, unsigned int *vgpuInstanceSamplesCount, nvmlVgpuInstanceUtilizationSample_t *utilizationSamples) {
+  if (nvmlDeviceGetVgpuUtilizationFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetVgpuUtilizationFunc(device, lastSeenTimeStamp, sampleValType, vgpuInstanceSamplesCount, utilizationSamples);
+}
+
+nvmlReturn_t (*nvmlDeviceGetVgpuProcessUtilizationFunc)(nvmlDevice_t device, unsigned long long lastSeenTimeStamp, unsigned int *vgpuProcessSamplesCount, nvmlVgpuProcessUtilizationSample_t *utilizationSamples);
+nvmlReturn_t nvmlDeviceGetVgpuProcessUtilization(nvmlDevice_t device, unsigned long long lastSeenTimeStamp, unsigned int *vgpuProcessSamplesCount, nvmlVgpuProcessUtilizationSample_t *utilizationSamples) {
+  if (nvmlDeviceGetVgpuProcessUtilizationFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetVgpuProcessUtilizationFunc(device, lastSeenTimeStamp, vgpuProcessSamplesCount, utilizationSamples);
+}
+
+nvmlReturn_t (*nvmlDeviceGetGridLicensableFeaturesFunc)(nvmlDevice_t device, nvmlGridLicensableFeatures_t *pGridLicensableFeatures);
+nvmlReturn_t nvmlDeviceGetGridLicensableFeatures(nvmlDevice_t device, nvmlGridLicensableFeatures_t *pGridLicensableFeatures) {
+  if (nvmlDeviceGetGridLicensableFeaturesFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetGridLicensableFeaturesFunc(device, pGridLicensableFeatures);
+}
+
+nvmlReturn_t (*nvmlDeviceGetPciInfoFunc)(nvmlDevice_t device, nvmlPciInfo_t *pci);
+nvmlReturn_t nvmlDeviceGetPciInfo(nvmlDevice_t device, nvmlPciInfo_t *pci) {
+  if (nvmlDeviceGetPciInfoFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetPciInfoFunc(device, pci);
+}
+
+nvmlReturn_t (*nvmlDeviceGetHandleByPciBusIdFunc)(const char *pciBusId, nvmlDevice_t *device);
+nvmlReturn_t nvmlDeviceGetHandleByPciBusId(const char *pciBusId, nvmlDevice_t *device) {
+  if (nvmlDeviceGetHandleByPciBusIdFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetHandleByPciBusIdFunc(pciBusId, device);
+}
+
+nvmlReturn_t (*nvmlDeviceQueryDrainStateFunc)(nvmlPciInfo_t *pciInfo, nvmlEnableState_t *currentState);
+nvmlReturn_t nvmlDeviceQueryDrainState(nvmlPciInfo_t *pciInfo, nvmlEnableState_t *currentState) {
+  if (nvmlDeviceQueryDrainStateFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceQueryDrainStateFunc(pciInfo, currentState);
+}
+
+nvmlReturn_t (*nvmlDeviceModifyDrainStateFunc)(nvmlPciInfo_t *pciInfo, nvmlEnableState_t newState);
+nvmlReturn_t nvmlDeviceModifyDrainState(nvmlPciInfo_t *pciInfo, nvmlEnableState_t newState) {
+  if (nvmlDeviceModifyDrainStateFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceModifyDrainStateFunc(pciInfo, newState);
+}
+
+nvmlReturn_t (*nvmlDeviceRemoveGpuFunc)(nvmlPciInfo_t *pciInfo);
+nvmlReturn_t nvmlDeviceRemoveGpu(nvmlPciInfo_t *pciInfo) {
+  if (nvmlDeviceRemoveGpuFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceRemoveGpuFunc(pciInfo);
+}
+
+nvmlReturn_t (*nvmlDeviceDiscoverGpusFunc)(nvmlPciInfo_t *pciInfo);
+nvmlReturn_t nvmlDeviceDiscoverGpus(nvmlPciInfo_t *pciInfo) {
+  if (nvmlDeviceDiscoverGpusFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceDiscoverGpusFunc(pciInfo);
+}
+
+nvmlReturn_t (*nvmlDeviceGetCurrPcieLinkGenerationFunc)(nvmlDevice_t device, unsigned int *currLinkGen);
+nvmlReturn_t nvmlDeviceGetCurrPcieLinkGeneration(nvmlDevice_t device, unsigned int *currLinkGen) {
+  if (nvmlDeviceGetCurrPcieLinkGenerationFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetCurrPcieLinkGenerationFunc(device, currLinkGen);
+}
+
+nvmlReturn_t (*nvmlDeviceGetMaxPcieLinkGenerationFunc)(nvmlDevice_t device, unsigned int *maxLinkGen);
+nvmlReturn_t nvmlDeviceGetMaxPcieLinkGeneration(nvmlDevice_t device, unsigned int *maxLinkGen) {
+  if (nvmlDeviceGetMaxPcieLinkGenerationFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
+  return nvmlDeviceGetMaxPcieLinkGenerationFunc(device, maxLinkGen);
+}
+
+nvmlReturn_t (*nvmlDeviceGetCurrPcieLinkWidthFunc)(nvmlDevice_t device, unsigned int *currLinkWidth);
+nvmlReturn_t nvmlDeviceGetCurrPcieLinkWidth(nvmlDevice_t device, unsigned int *currLinkWidth) {
+  if (nvmlDeviceGetCurrPcieLinkWidthFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetCurrPcieLinkWidthFunc(device, currLinkWidth);
+}
+
+nvmlReturn_t (*nvmlDeviceGetMaxPcieLinkWidthFunc)(nvmlDevice_t device, unsigned int *maxLinkWidth);
+nvmlReturn_t nvmlDeviceGetMaxPcieLinkWidth(nvmlDevice_t device, unsigned int *maxLinkWidth) {
+  if (nvmlDeviceGetMaxPcieLinkWidthFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetMaxPcieLinkWidthFunc(device, maxLinkWidth);
+}
+
+nvmlReturn_t (*nvmlDeviceGetPcieThroughputFunc)(nvmlDevice_t device, nvmlPcieUtilCounter_t counter, unsigned int *value);
+nvmlReturn_t nvmlDeviceGetPcieThroughput(nvmlDevice_t device, nvmlPcieUtilCounter_t counter, unsigned int *value) {
+  if (nvmlDeviceGetPcieThroughputFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetPcieThroughputFunc(device, counter, value);
+}
+
+nvmlReturn_t (*nvmlDeviceGetPcieReplayCounterFunc)(nvmlDevice_t device, unsigned int *value);
+nvmlReturn_t nvmlDeviceGetPcieReplayCounter(nvmlDevice_t device, unsigned int *value) {
+  if (nvmlDeviceGetPcieReplayCounterFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetPcieReplayCounterFunc(device, value);
+}
+
+nvmlReturn_t (*nvmlDeviceGetDriverModelFunc)(nvmlDevice_t device, nvmlDriverModel_t *current, nvmlDriverModel_t *pending);
+nvmlReturn_t nvmlDeviceGetDriverModel(nvmlDevice_t device, nvmlDriverModel_t *current, nvmlDriverModel_t *pending) {
+  if (nvmlDeviceGetDriverModelFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetDriverModelFunc(device, current, pending);
+}
+
+nvmlReturn_t (*nvmlDeviceGetGpuOperationModeFunc)(nvmlDevice_t device, nvmlGpuOperationMode_t *current, nvmlGpuOperationMode_t *pending);
+nvmlReturn_t nvmlDeviceGetGpuOperationMode(nvmlDevice_t device, nvmlGpuOperationMode_t *current, nvmlGpuOperationMode_t *pending) {
+  if (nvmlDeviceGetGpuOperationModeFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetGpuOperationModeFunc(device, current, pending);
+}
+
+nvmlReturn_t (*nvmlDeviceGetVirtualizationModeFunc)(nvmlDevice_t device, nvmlGpuVirtualizationMode_t *pVirtualMode);
+nvmlReturn_t nvmlDeviceGetVirtualizationMode(nvmlDevice_t device, nvmlGpuVirtualizationMode_t *pVirtualMode) {
+  if (nvmlDeviceGetVirtualizationModeFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetVirtualizationModeFunc(device, pVirtualMode);
+}
+
+nvmlReturn_t (*nvmlSystemGetProcessNameFunc)(unsigned int pid, char *name, unsigned int length);
+nvmlReturn_t nvmlSystemGetProcessName(unsigned int pid, char *name, unsigned int length) {
+  if (nvmlSystemGetProcessNameFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlSystemGetProcessNameFunc(pid, name, length);
+}
+
+nvmlReturn_t (*nvmlDeviceGetComputeRunningProcessesFunc)(nvmlDevice_t device, unsigned int *infoCount, nvmlProcessInfo_t *infos);
+nvmlReturn_t nvmlDeviceGetComputeRunningProcesses(nvmlDevice_t device, unsigned int *infoCount, nvmlProcessInfo_t *infos) {
+  if (nvmlDeviceGetComputeRunningProcessesFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetComputeRunningProcessesFunc(device, infoCount, infos);
+}
+
+nvmlReturn_t (*nvmlDeviceGetGraphicsRunningProcessesFunc)(nvmlDevice_t device, unsigned int *infoCount, nvmlProcessInfo_t *infos);
+nvmlReturn_t nvmlDeviceGetGraphicsRunningProcesses(nvmlDevice_t device, unsigned int *infoCount, nvmlProcessInfo_t *infos) {
+  if (nvmlDeviceGetGraphicsRunningProcessesFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetGraphicsRunningProcessesFunc(device, infoCount, infos);
+}
+
+nvmlReturn_t (*nvmlDeviceSetPersistenceModeFunc)(nvmlDevice_t device, nvmlEnableState_t mode);
+nvmlReturn_t nvmlDeviceSetPersistenceMode(nvmlDevice_t device, nvmlEnableState_t mode) {
+  if (nvmlDeviceSetPersistenceModeFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceSetPersistenceModeFunc(device, mode);
+}
+
+nvmlReturn_t (*nvmlDeviceSetComputeModeFunc)(nvmlDevice_t device, nvmlComputeMode_t mode);
+nvmlReturn_t nvmlDeviceSetComputeMode(nvmlDevice_t device, nvmlComputeMode_t mode) {
+  if (nvmlDeviceSetComputeModeFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceSetComputeModeFunc(device, mode);
+}
+
+nvmlReturn_t (*nvmlDeviceGetPowerManagementLimitFunc)(nvmlDevice_t device, unsigned int *limit);
+nvmlReturn_t nvmlDeviceGetPowerManagementLimit(nvmlDevice_t device, unsigned int *limit) {
+  if (nvmlDeviceGetPowerManagementLimitFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetPowerManagementLimitFunc(device, limit);
+}
+
+nvmlReturn_t (*nvmlDeviceGetPowerManagementLimitConstraintsFunc)(nvmlDevice_t device, unsigned int *minLimit, unsigned int *maxLimit);
+nvmlReturn_t nvmlDeviceGetPowerManagementLimitConstraints(nvmlDevice_t device, unsigned int *minLimit, unsigned int *maxLimit) {
+  if (nvmlDeviceGetPowerManagementLimitConstraintsFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetPowerManagementLimitConstraintsFunc(device, minLimit, maxLimit);
+}
+
+nvmlReturn_t (*nvmlDeviceGetPowerManagementDefaultLimitFunc)(nvmlDevice_t device, unsigned int *defaultLimit);
+nvmlReturn_t nvmlDeviceGetPowerManagementDefaultLimit(nvmlDevice_t device, unsigned int *defaultLimit) {
+  if (nvmlDeviceGetPowerManagementDefaultLimitFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetPowerManagementDefaultLimitFunc(device, defaultLimit);
+}
+
+nvmlReturn_t (*nvmlDeviceSetPowerManagementLimitFunc)(nvmlDevice_t device, unsigned int limit);
+nvmlReturn_t nvmlDeviceSetPowerManagementLimit(nvmlDevice_t device, unsigned int limit) {
+  if (nvmlDeviceSetPowerManagementLimitFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceSetPowerManagementLimitFunc(device, limit);
+}
+
+nvmlReturn_t (*nvmlDeviceGetApplicationsClockFunc)(nvmlDevice_t device, nvmlClockType_t clockType, unsigned int *clockMHz);
+nvmlReturn_t nvmlDeviceGetApplicationsClock(nvmlDevice_t device, nvmlClockType_t clockType, unsigned int *clockMHz) {
+  if (nvmlDeviceGetApplicationsClockFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetApplicationsClockFunc(device, clockType, clockMHz);
+}
+
+nvmlReturn_t (*nvmlDeviceGetDefaultApplicationsClockFunc)(nvmlDevice_t device, nvmlClockType_t clockType, unsigned int *clockMHz);
+nvmlReturn_t nvmlDeviceGetDefaultApplicationsClock(nvmlDevice_t device, nvmlClockType_t clockType, unsigned int *clockMHz) {
+  if (nvmlDeviceGetDefaultApplicationsClockFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetDefaultApplicationsClockFunc(device, clockType, clockMHz);
+}
+
+nvmlReturn_t (*nvmlDeviceGetSupportedMemoryClocksFunc)(nvmlDevice_t device, unsigned int *count, unsigned int *clocksMHz);
+nvmlReturn_t nvmlDeviceGetSupportedMemoryClocks(nvmlDevice_t device, unsigned int *count, unsigned int *clocksMHz) {
+  if (nvmlDeviceGetSupportedMemoryClocksFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetSupportedMemoryClocksFunc(device, count, clocksMHz);
+}
+
+nvmlReturn_t (*nvmlDeviceGetSupportedGraphicsClocksFunc)(nvmlDevice_t device, unsigned int memoryClockMHz, unsigned int *count, unsigned int *clocksMHz);
+nvmlReturn_t nvmlDeviceGetSupportedGraphicsClocks(nvmlDevice_t device, unsigned int memoryClockMHz, unsigned int *count, unsigned int *clocksMHz) {
+  if (nvmlDeviceGetSupportedGraphicsClocksFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetSupportedGraphicsClocksFunc(device, memoryClockMHz, count, clocksMHz);
+}
+
+nvmlReturn_t (*nvmlDeviceSetApplicationsClocksFunc)(nvmlDevice_t device, unsigned int memClockMHz, unsigned int graphicsClockMHz);
+nvmlReturn_t nvmlDeviceSetApplicationsClocks(nvmlDevice_t device, unsigned int memClockMHz, unsigned int graphicsClockMHz) {
+  if (nvmlDeviceSetApplicationsClocksFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceSetApplicationsClocksFunc(device, memClockMHz, graphicsClockMHz);
+}
+
+nvmlReturn_t (*nvmlDeviceResetApplicationsClocksFunc)(nvmlDevice_t device);
+nvmlReturn_t nvmlDeviceResetApplicationsClocks(nvmlDevice_t device) {
+  if (nvmlDeviceResetApplicationsClocksFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceResetApplicationsClocksFunc(device);
+}
+
+nvmlReturn_t (*nvmlDeviceGetAutoBoostedClocksEnabledFunc)(nvmlDevice_t device, nvmlEnableState_t *isEnabled, nvmlEnableState_t *defaultIsEnabled);
+nvmlReturn_t nvmlDeviceGetAutoBoostedClocksEnabled(nvmlDevice_t device, nvmlEnableState_t *isEnabled, nvmlEnableState_t *defaultIsEnabled) {
+  if (nvmlDeviceGetAutoBoostedClocksEnabledFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetAutoBoostedClocksEnabledFunc(device, isEnabled, defaultIsEnabled);
+}
+
+nvmlReturn_t (*nvmlDeviceSetAutoBoostedClocksEnabledFunc)(nvmlDevice_t device, nvmlEnableState_t enabled);
+nvmlReturn_t nvmlDeviceSetAutoBoostedClocksEnabled(nvmlDevice_t device, nvmlEnableState_t enabled) {
+  if (nvmlDeviceSetAutoBoostedClocksEnabledFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceSetAutoBoostedClocksEnabledFunc(device, enabled);
+}
+
+nvmlReturn_t (*nvmlDeviceSetDefaultAutoBoostedClocksEnabledFunc)(nvmlDevice_t device, nvmlEnableState_t enabled, unsigned int flags);
+nvmlReturn_t nvmlDeviceSetDefaultAutoBoostedClocksEnabled(nvmlDevice_t device, nvmlEnableState_t enabled, unsigned int flags) {
+  if (nvmlDeviceSetDefaultAutoBoostedClocksEnabledFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceSetDefaultAutoBoostedClocksEnabledFunc(device, enabled, flags);
+}
+
+nvmlReturn_t (*nvmlDeviceGetEccModeFunc)(nvmlDevice_t device, nvmlEnableState_t *current, nvmlEnableState_t *pending);
+nvmlReturn_t nvmlDeviceGetEccMode(nvmlDevice_t device, nvmlEnableState_t *current, nvmlEnableState_t *pending) {
+  if (nvmlDeviceGetEccModeFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetEccModeFunc(device, current, pending);
+}
+
+nvmlReturn_t (*nvmlDeviceSetEccModeFunc)(nvmlDevice_t device, nvmlEnableState_t ecc);
+nvmlReturn_t nvmlDeviceSetEccMode(nvmlDevice_t device, nvmlEnableState_t ecc) {
+  if (nvmlDeviceSetEccModeFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceSetEccModeFunc(device, ecc);
+}
+
+nvmlReturn_t (*nvmlDeviceClearEccErrorCountsFunc)(nvmlDevice_t device, nvmlEccCounterType_t counterType);
+nvmlReturn_t nvmlDeviceClearEccErrorCounts(nvmlDevice_t device, nvmlEccCounterType_t counterType) {
+  if (nvmlDeviceClearEccErrorCountsFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceClearEccErrorCountsFunc(device, counterType);
+}
+
+nvmlReturn_t (*nvmlDeviceGetAccountingModeFunc)(nvmlDevice_t device, nvmlEnableState_t *mode);
+nvmlReturn_t nvmlDeviceGetAccountingMode(nvmlDevice_t device, nvmlEnableState_t *mode) {
+  if (nvmlDeviceGetAccountingModeFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetAccountingModeFunc(device, mode);
+}
+
+nvmlReturn_t (*nvmlDeviceSetAccountingModeFunc)(nvmlDevice_t device, nvmlEnableState_t mode);
+nvmlReturn_t nvmlDeviceSetAccountingMode(nvmlDevice_t device, nvmlEnableState_t mode) {
+  if (nvmlDeviceSetAccountingModeFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceSetAccountingModeFunc(device, mode);
+}
+
+nvmlReturn_t (*nvmlDeviceClearAccountingPidsFunc)(nvmlDevice_t device);
+nvmlReturn_t nvmlDeviceClearAccountingPids(nvmlDevice_t device) {
+  if (nvmlDeviceClearAccountingPidsFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceClearAccountingPidsFunc(device);
+}
+
+nvmlReturn_t (*nvmlDeviceGetAPIRestrictionFunc)(nvmlDevice_t device, nvmlRestrictedAPI_t apiType, nvmlEnableState_t *isRestricted);
+nvmlReturn_t nvmlDeviceGetAPIRestriction(nvmlDevice_t device, nvmlRestrictedAPI_t apiType, nvmlEnableState_t *isRestricted) {
+  if (nvmlDeviceGetAPIRestrictionFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetAPIRestrictionFunc(device, apiType, isRestricted);
+}
+
+nvmlReturn_t (*nvmlDeviceSetAPIRestrictionFunc)(nvmlDevice_t device, nvmlRestrictedAPI_t apiType, nvmlEnableState_t isRestricted);
+nvmlReturn_t nvmlDeviceSetAPIRestriction(nvmlDevice_t device, nvmlRestrictedAPI_t apiType, nvmlEnableState_t isRestricted) {
+  if (nvmlDeviceSetAPIRestrictionFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceSetAPIRestrictionFunc(device, apiType, isRestricted);
+}
+
+nvmlReturn_t (*nvmlDeviceGetCurrentClocksThrottleReasonsFunc)(nvmlDevice_t device, unsigned long long *clocksThrottleReasons);
+nvmlReturn_t nvmlDeviceGetCurrentClocksThrottleReasons(nvmlDevice_t device, unsigned long long *clocksThrottleReasons) {
+  if (nvmlDeviceGetCurrentClocksThrottleReasonsFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetCurrentClocksThrottleReasonsFunc(device, clocksThrottleReasons);
+}
+
+nvmlReturn_t (*nvmlDeviceGetInforomImageVersionFunc)(nvmlDevice_t device, char *version, unsigned int length);
+nvmlReturn_t nvmlDeviceGetInforomImageVersion(nvmlDevice_t device, char *version, unsigned int length) {
+  if (nvmlDeviceGetInforomImageVersionFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetInforomImageVersionFunc(device, version, length);
+}
+
+nvmlReturn_t (*nvmlDeviceGetSamplesFunc)(nvmlDevice_t device, nvmlSamplingType_t type, unsigned long long lastSeenTimeStamp, nvmlValueType_t *sampleValType, unsigned int *sampleCount, nvmlSample_t *samples);
+nvmlReturn_t nvmlDeviceGetSamples(nvmlDevice_t device, nvmlSamplingType_t type, unsigned long long lastSeenTimeStamp, nvmlValueType_t *sampleValType, unsigned int *sampleCount, nvmlSample_t *samples) {
+  if (nvmlDeviceGetSamplesFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetSamplesFunc(device, type, lastSeenTimeStamp, sampleValType, sampleCount, samples);
+}
+
+// Loads the "libnvidia-ml.so.1" shared library.
+// Loads all symbols needed and initializes NVML.
+// Call this before calling any other methods.
+nvmlReturn_t nvmlInit_dl(void) {
+  nvmlHandle = dlopen("libnvidia-ml.so.1", RTLD_LAZY);
+  if (nvmlHandle == NULL) {
+    return NVML_ERROR_LIBRARY_NOT_FOUND;
+  }
+  nvmlInitFunc = dlsym(nvmlHandle, "nvmlInit_v2");
+  if (nvmlInitFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlShutdownFunc = dlsym(nvmlHandle, "nvmlShutdown");
+  if (nvmlShutdownFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlErrorStringFunc = dlsym(nvmlHandle, "nvmlErrorString");
+  if (nvmlErrorStringFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlSystemGetDriverVersionFunc = dlsym(nvmlHandle, "nvmlSystemGetDriverVersion");
+  if (nvmlSystemGetDriverVersionFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetCountFunc = dlsym(nvmlHandle, "nvmlDeviceGetCount_v2");
+  if (nvmlDeviceGetCountFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetHandleByIndexFunc = dlsym(nvmlHandle, "nvmlDeviceGetHandleByIndex_v2");
+  if (nvmlDeviceGetHandleByIndexFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetMinorNumberFunc = dlsym(nvmlHandle, "nvmlDeviceGetMinorNumber");
+  if (nvmlDeviceGetMinorNumberFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetUUIDFunc = dlsym(nvmlHandle, "nvmlDeviceGetUUID");
+  if (nvmlDeviceGetUUIDFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetNameFunc = dlsym(nvmlHandle, "nvmlDeviceGetName");
+  if (nvmlDeviceGetNameFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetMemoryInfoFunc = dlsym(nvmlHandle, "nvmlDeviceGetMemoryInfo");
+  if (nvmlDeviceGetMemoryInfoFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetUtilizationRatesFunc = dlsym(nvmlHandle, "nvmlDeviceGetUtilizationRates");
+  if (nvmlDeviceGetUtilizationRatesFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetPowerUsageFunc = dlsym(nvmlHandle, "nvmlDeviceGetPowerUsage");
+  if (nvmlDeviceGetPowerUsageFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetTemperatureFunc = dlsym(nvmlHandle, "nvmlDeviceGetTemperature");
+  if (nvmlDeviceGetTemperatureFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetFanSpeedFunc = dlsym(nvmlHandle, "nvmlDeviceGetFanSpeed");
+  if (nvmlDeviceGetFanSpeedFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetSamplesFunc = dlsym(nvmlHandle, "nvmlDeviceGetSamples");
+  if (nvmlDeviceGetSamplesFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetEncoderUtilizationFunc = dlsym(nvmlHandle, "nvmlDeviceGetEncoderUtilization");
+  if (nvmlDeviceGetEncoderUtilizationFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetDecoderUtilizationFunc = dlsym(nvmlHandle, "nvmlDeviceGetDecoderUtilization");
+  if (nvmlDeviceGetDecoderUtilizationFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  // The symbols below are optional. Not every driver exports all of them, so
+  // a missing symbol does not fail initialization; the corresponding wrapper
+  // returns NVML_ERROR_FUNCTION_NOT_FOUND instead.
+  nvmlDeviceGetVbiosVersionFunc = dlsym(nvmlHandle, "nvmlDeviceGetVbiosVersion");
+  nvmlDeviceGetSerialFunc = dlsym(nvmlHandle, "nvmlDeviceGetSerial");
+  nvmlDeviceGetBoardPartNumberFunc = dlsym(nvmlHandle, "nvmlDeviceGetBoardPartNumber");
+  nvmlDeviceGetBrandFunc = dlsym(nvmlHandle, "nvmlDeviceGetBrand");
+  nvmlDeviceGetBoardIdFunc = dlsym(nvmlHandle, "nvmlDeviceGetBoardId");
+  nvmlDeviceGetMultiGpuBoardFunc = dlsym(nvmlHandle, "nvmlDeviceGetMultiGpuBoard");
+  nvmlDeviceGetIndexFunc = dlsym(nvmlHandle, "nvmlDeviceGetIndex");
+  nvmlDeviceGetBridgeChipInfoFunc = dlsym(nvmlHandle, "nvmlDeviceGetBridgeChipInfo");
+  nvmlDeviceGetComputeModeFunc = dlsym(nvmlHandle, "nvmlDeviceGetComputeMode");
+  nvmlDeviceGetPersistenceModeFunc = dlsym(nvmlHandle, "nvmlDeviceGetPersistenceMode");
+  nvmlDeviceGetDisplayModeFunc = dlsym(nvmlHandle, "nvmlDeviceGetDisplayMode");
+  nvmlDeviceGetDisplayActiveFunc = dlsym(nvmlHandle, "nvmlDeviceGetDisplayActive");
+  nvmlSystemGetNVMLVersionFunc = dlsym(nvmlHandle, "nvmlSystemGetNVMLVersion");
+  nvmlSystemGetCudaDriverVersionFunc = dlsym(nvmlHandle, "nvmlSystemGetCudaDriverVersion");
+  nvmlDeviceGetCudaComputeCapabilityFunc = dlsym(nvmlHandle, "nvmlDeviceGetCudaComputeCapability");
+  nvmlDeviceGetTemperatureThresholdFunc = dlsym(nvmlHandle, "nvmlDeviceGetTemperatureThreshold");
+  nvmlDeviceGetEncoderCapacityFunc = dlsym(nvmlHandle, "nvmlDeviceGetEncoderCapacity");
+  nvmlDeviceGetEncoderStatsFunc = dlsym(nvmlHandle, "nvmlDeviceGetEncoderStats");
+  nvmlDeviceGetEncoderSessionsFunc = dlsym(nvmlHandle, "nvmlDeviceGetEncoderSessions");
+  nvmlUnitGetCountFunc = dlsym(nvmlHandle, "nvmlUnitGetCount");
+  nvmlUnitGetHandleByIndexFunc = dlsym(nvmlHandle, "nvmlUnitGetHandleByIndex");
+  nvmlUnitGetUnitInfoFunc = dlsym(nvmlHandle, "nvmlUnitGetUnitInfo");
+  nvmlUnitGetLedStateFunc = dlsym(nvmlHandle, "nvmlUnitGetLedState");
+  nvmlUnitGetPsuInfoFunc = dlsym(nvmlHandle, "nvmlUnitGetPsuInfo");
+  nvmlUnitGetTemperatureFunc = dlsym(nvmlHandle, "nvmlUnitGetTemperature");
+  nvmlUnitGetFanSpeedInfoFunc = dlsym(nvmlHandle, "nvmlUnitGetFanSpeedInfo");
+  nvmlUnitGetDevicesFunc = dlsym(nvmlHandle, "nvmlUnitGetDevices");
+  nvmlDeviceGetSupportedVgpusFunc = dlsym(nvmlHandle, "nvmlDeviceGetSupportedVgpus");
+  nvmlDeviceGetCreatableVgpusFunc = dlsym(nvmlHandle, "nvmlDeviceGetCreatableVgpus");
+  nvmlVgpuTypeGetClassFunc = dlsym(nvmlHandle, "nvmlVgpuTypeGetClass");
+  nvmlVgpuTypeGetNameFunc = dlsym(nvmlHandle, "nvmlVgpuTypeGetName");
+  nvmlVgpuTypeGetDeviceIDFunc = dlsym(nvmlHandle, "nvmlVgpuTypeGetDeviceID");
+  nvmlVgpuTypeGetFramebufferSizeFunc = dlsym(nvmlHandle, "nvmlVgpuTypeGetFramebufferSize");
+  nvmlVgpuTypeGetNumDisplayHeadsFunc = dlsym(nvmlHandle, "nvmlVgpuTypeGetNumDisplayHeads");
+  nvmlVgpuTypeGetResolutionFunc = dlsym(nvmlHandle, "nvmlVgpuTypeGetResolution");
+  nvmlVgpuTypeGetLicenseFunc = dlsym(nvmlHandle, "nvmlVgpuTypeGetLicense");
+  nvmlVgpuTypeGetFrameRateLimitFunc = dlsym(nvmlHandle, "nvmlVgpuTypeGetFrameRateLimit");
+  nvmlVgpuTypeGetMaxInstancesFunc = dlsym(nvmlHandle, "nvmlVgpuTypeGetMaxInstances");
+  nvmlDeviceGetActiveVgpusFunc = dlsym(nvmlHandle, "nvmlDeviceGetActiveVgpus");
+  nvmlVgpuInstanceGetVmIDFunc = dlsym(nvmlHandle, "nvmlVgpuInstanceGetVmID");
+  nvmlVgpuInstanceGetUUIDFunc = dlsym(nvmlHandle, "nvmlVgpuInstanceGetUUID");
+  nvmlVgpuInstanceGetVmDriverVersionFunc = dlsym(nvmlHandle, "nvmlVgpuInstanceGetVmDriverVersion");
+  nvmlVgpuInstanceGetFbUsageFunc = dlsym(nvmlHandle, "nvmlVgpuInstanceGetFbUsage");
+  nvmlVgpuInstanceGetLicenseStatusFunc = dlsym(nvmlHandle, "nvmlVgpuInstanceGetLicenseStatus");
+  nvmlVgpuInstanceGetTypeFunc = dlsym(nvmlHandle, "nvmlVgpuInstanceGetType");
+  nvmlVgpuInstanceGetFrameRateLimitFunc = dlsym(nvmlHandle, "nvmlVgpuInstanceGetFrameRateLimit");
+  nvmlVgpuInstanceGetEncoderCapacityFunc = dlsym(nvmlHandle, "nvmlVgpuInstanceGetEncoderCapacity");
+  nvmlVgpuInstanceGetEncoderStatsFunc = dlsym(nvmlHandle, "nvmlVgpuInstanceGetEncoderStats");
+  nvmlVgpuInstanceGetEncoderSessionsFunc = dlsym(nvmlHandle, "nvmlVgpuInstanceGetEncoderSessions");
+  nvmlDeviceGetVgpuUtilizationFunc = dlsym(nvmlHandle, "nvmlDeviceGetVgpuUtilization");
+  nvmlDeviceGetVgpuProcessUtilizationFunc = dlsym(nvmlHandle, "nvmlDeviceGetVgpuProcessUtilization");
+  nvmlDeviceGetGridLicensableFeaturesFunc = dlsym(nvmlHandle, "nvmlDeviceGetGridLicensableFeatures");
+  nvmlDeviceGetPciInfoFunc = dlsym(nvmlHandle, "nvmlDeviceGetPciInfo_v2");
+  nvmlDeviceGetHandleByPciBusIdFunc = dlsym(nvmlHandle, "nvmlDeviceGetHandleByPciBusId_v2");
+  nvmlDeviceQueryDrainStateFunc = dlsym(nvmlHandle, "nvmlDeviceQueryDrainState");
+  nvmlDeviceModifyDrainStateFunc = dlsym(nvmlHandle, "nvmlDeviceModifyDrainState");
+  nvmlDeviceRemoveGpuFunc = dlsym(nvmlHandle, "nvmlDeviceRemoveGpu");
+  nvmlDeviceDiscoverGpusFunc = dlsym(nvmlHandle, "nvmlDeviceDiscoverGpus");
+  nvmlDeviceGetCurrPcieLinkGenerationFunc = dlsym(nvmlHandle, "nvmlDeviceGetCurrPcieLinkGeneration");
+  nvmlDeviceGetMaxPcieLinkGenerationFunc = dlsym(nvmlHandle, "nvmlDeviceGetMaxPcieLinkGeneration");
+  nvmlDeviceGetCurrPcieLinkWidthFunc = dlsym(nvmlHandle, "nvmlDeviceGetCurrPcieLinkWidth");
+  nvmlDeviceGetMaxPcieLinkWidthFunc = dlsym(nvmlHandle, "nvmlDeviceGetMaxPcieLinkWidth");
+  nvmlDeviceGetPcieThroughputFunc = dlsym(nvmlHandle, "nvmlDeviceGetPcieThroughput");
+  nvmlDeviceGetPcieReplayCounterFunc = dlsym(nvmlHandle, "nvmlDeviceGetPcieReplayCounter");
+  nvmlDeviceGetDriverModelFunc = dlsym(nvmlHandle, "nvmlDeviceGetDriverModel");
+  nvmlDeviceGetGpuOperationModeFunc = dlsym(nvmlHandle, "nvmlDeviceGetGpuOperationMode");
+  nvmlDeviceGetVirtualizationModeFunc = dlsym(nvmlHandle, "nvmlDeviceGetVirtualizationMode");
+  nvmlSystemGetProcessNameFunc = dlsym(nvmlHandle, "nvmlSystemGetProcessName");
+  nvmlDeviceGetComputeRunningProcessesFunc = dlsym(nvmlHandle, "nvmlDeviceGetComputeRunningProcesses");
+  nvmlDeviceGetGraphicsRunningProcessesFunc = dlsym(nvmlHandle, "nvmlDeviceGetGraphicsRunningProcesses");
+  nvmlDeviceSetPersistenceModeFunc = dlsym(nvmlHandle, "nvmlDeviceSetPersistenceMode");
+  nvmlDeviceSetComputeModeFunc = dlsym(nvmlHandle, "nvmlDeviceSetComputeMode");
+  nvmlDeviceGetPowerManagementLimitFunc = dlsym(nvmlHandle, "nvmlDeviceGetPowerManagementLimit");
+  nvmlDeviceGetPowerManagementLimitConstraintsFunc = dlsym(nvmlHandle, "nvmlDeviceGetPowerManagementLimitConstraints");
+  nvmlDeviceGetPowerManagementDefaultLimitFunc = dlsym(nvmlHandle, "nvmlDeviceGetPowerManagementDefaultLimit");
+  nvmlDeviceSetPowerManagementLimitFunc = dlsym(nvmlHandle, "nvmlDeviceSetPowerManagementLimit");
+  nvmlDeviceGetApplicationsClockFunc = dlsym(nvmlHandle, "nvmlDeviceGetApplicationsClock");
+  nvmlDeviceGetDefaultApplicationsClockFunc = dlsym(nvmlHandle, "nvmlDeviceGetDefaultApplicationsClock");
+  nvmlDeviceGetSupportedMemoryClocksFunc = dlsym(nvmlHandle, "nvmlDeviceGetSupportedMemoryClocks");
+  nvmlDeviceGetSupportedGraphicsClocksFunc = dlsym(nvmlHandle, "nvmlDeviceGetSupportedGraphicsClocks");
+  nvmlDeviceSetApplicationsClocksFunc = dlsym(nvmlHandle, "nvmlDeviceSetApplicationsClocks");
+  nvmlDeviceResetApplicationsClocksFunc = dlsym(nvmlHandle, "nvmlDeviceResetApplicationsClocks");
+  nvmlDeviceGetAutoBoostedClocksEnabledFunc = dlsym(nvmlHandle, "nvmlDeviceGetAutoBoostedClocksEnabled");
+  nvmlDeviceSetAutoBoostedClocksEnabledFunc = dlsym(nvmlHandle, "nvmlDeviceSetAutoBoostedClocksEnabled");
+  nvmlDeviceSetDefaultAutoBoostedClocksEnabledFunc = dlsym(nvmlHandle, "nvmlDeviceSetDefaultAutoBoostedClocksEnabled");
+  nvmlDeviceGetEccModeFunc = dlsym(nvmlHandle, "nvmlDeviceGetEccMode");
+  nvmlDeviceSetEccModeFunc = dlsym(nvmlHandle, "nvmlDeviceSetEccMode");
+  nvmlDeviceClearEccErrorCountsFunc = dlsym(nvmlHandle, "nvmlDeviceClearEccErrorCounts");
+  nvmlDeviceGetAccountingModeFunc = dlsym(nvmlHandle, "nvmlDeviceGetAccountingMode");
+  nvmlDeviceSetAccountingModeFunc = dlsym(nvmlHandle, "nvmlDeviceSetAccountingMode");
+  nvmlDeviceClearAccountingPidsFunc = dlsym(nvmlHandle, "nvmlDeviceClearAccountingPids");
+  nvmlDeviceGetAPIRestrictionFunc = dlsym(nvmlHandle, "nvmlDeviceGetAPIRestriction");
+  nvmlDeviceSetAPIRestrictionFunc = dlsym(nvmlHandle, "nvmlDeviceSetAPIRestriction");
+  nvmlDeviceGetCurrentClocksThrottleReasonsFunc = dlsym(nvmlHandle, "nvmlDeviceGetCurrentClocksThrottleReasons");
+  nvmlDeviceGetInforomImageVersionFunc = dlsym(nvmlHandle, "nvmlDeviceGetInforomImageVersion");
+  nvmlReturn_t result = nvmlInitFunc();
+  if (result != NVML_SUCCESS) {
+    dlclose(nvmlHandle);
+    nvmlHandle = NULL;
+    return result;
+  }
+  return NVML_SUCCESS;
+}
+
+// Shuts down NVML and decrements the reference count on the dynamically loaded
+// "libnvidia-ml.so.1" library.
+// Call this once NVML is no longer being used.
+nvmlReturn_t nvmlShutdown_dl(void) {
+  if (nvmlHandle == NULL) {
+    return NVML_SUCCESS;
+  }
+  if (nvmlShutdownFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlReturn_t r = nvmlShutdownFunc();
+  if (r != NVML_SUCCESS) {
+    return r;
+  }
+  return (dlclose(nvmlHandle) ? NVML_ERROR_UNKNOWN : NVML_SUCCESS);
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"unsafe"
+)
+
+const (
+	szDriver     = C.NVML_SYSTEM_DRIVER_VERSION_BUFFER_SIZE
+	szNVML       = C.NVML_SYSTEM_NVML_VERSION_BUFFER_SIZE
+	szName       = C.NVML_DEVICE_NAME_BUFFER_SIZE
+	szUUID       = C.NVML_DEVICE_UUID_BUFFER_SIZE
+	szVbios      = C.NVML_DEVICE_VBIOS_VERSION_BUFFER_SIZE
+	szSerial     = C.NVML_DEVICE_SERIAL_BUFFER_SIZE
+	szPartNumber = C.NVML_DEVICE_PART_NUMBER_BUFFER_SIZE
+	szInforom    = C.NVML_DEVICE_INFOROM_VERSION_BUFFER_SIZE
+	szLicense    = C.NVML_GRID_LICENSE_BUFFER_SIZE
+	szVgpuName   = C.NVML_VGPU_NAME_BUFFER_SIZE
+	szProcName   = 1024
+)
+
+var errLibraryNotLoaded = errors.New("could not load NVML library")
+
+// defaultBackend returns the Backend used until SetBackend() is called.
+func defaultBackend() Backend {
+	return nvmlBackend{}
+}
+
+// nvmlBackend is the Backend that calls into the dynamically loaded NVML.
+type nvmlBackend struct{}
+
+// VgpuType returns the handle for the vGPU type with the given ID.
+func (nvmlBackend) VgpuType(t VgpuType) VgpuTypeBackend {
+	return nvmlVgpuType(t)
+}
+
+// VgpuInstance returns the handle for the vGPU instance with the given ID.
+func (nvmlBackend) VgpuInstance(v VgpuInstance) VgpuInstanceBackend {
+	return nvmlVgpuInstance(v)
+}
+
+// nvmlDevice, nvmlUnit, nvmlVgpuType and nvmlVgpuInstance are the NVML
+// implementations of the handle interfaces.
+type nvmlDevice struct {
+	dev C.nvmlDevice_t
+}
+
+type nvmlVgpuType VgpuType
+
+type nvmlVgpuInstance VgpuInstance
+
+// Initialize initializes NVML.
+// Call this before calling any other methods.
+func (nvmlBackend) Initialize() error {
+	return errorString(C.nvmlInit_dl())
+}
+
+// Shutdown shuts down NVML.
+// Call this once NVML is no longer being used.
+func (nvmlBackend) Shutdown() error {
+	return errorString(C.nvmlShutdown_dl())
+}
+
+// errorString takes a nvmlReturn_t and converts it into a golang error.
+// It uses a nvml method to convert to a user friendly error message.
+func errorString(ret C.nvmlReturn_t) error {
+	if ret == C.NVML_SUCCESS {
+		return nil
+	}
+	// We need to special case this because if nvml library is not found
+	// nvmlErrorString() method will not work.
+	if ret == C.NVML_ERROR_LIBRARY_NOT_FOUND || C.nvmlHandle == nil {
+		return errLibraryNotLoaded
+	}
+	switch ret {
+	case C.NVML_ERROR_NOT_SUPPORTED:
+		return ErrNotSupported
+	case C.NVML_ERROR_NO_PERMISSION:
+		return ErrNoPermission
+	case C.NVML_ERROR_FUNCTION_NOT_FOUND:
+		return ErrFunctionNotFound
+	}
+	err := C.GoString(C.nvmlErrorString(ret))
+	return fmt.Errorf("nvml: %v", err)
+}
+
+// SystemDriverVersion returns the the driver version on the system.
+func (nvmlBackend) SystemDriverVersion() (string, error) {
+	if C.nvmlHandle == nil {
+		return "", errLibraryNotLoaded
+	}
+	var driver [szDriver]C.char
+	r := C.nvmlSystemGetDriverVersion(&driver[0], szDriver)
+	return C.GoString(&driver[0]), errorString(r)
+}
+
+// SystemNVMLVersion returns the version of the NVML library.
+func (nvmlBackend) SystemNVMLVersion() (string, error) {
+	if C.nvmlHandle == nil {
+		return "", errLibraryNotLoaded
+	}
+	var version [szNVML]C.char
+	r := C.nvmlSystemGetNVMLVersion(&version[0], szNVML)
+	return C.GoString(&version[0]), errorString(r)
+}
+
+// SystemCudaDriverVersion returns the version of the CUDA driver on the
+// system. This is the same value that cuDriverGetVersion() would return, i.e.
+// 1000*major + 10*minor, e.g. 9010 for CUDA 9.1.
+func (nvmlBackend) SystemCudaDriverVersion() (int, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var version C.int
+	r := C.nvmlSystemGetCudaDriverVersion(&version)
+	return int(version), errorString(r)
+}
+
+// SystemProcessName returns the name of the process with the given PID.
+func (nvmlBackend) SystemProcessName(pid uint) (string, error) {
+	if C.nvmlHandle == nil {
+		return "", errLibraryNotLoaded
+	}
+	var name [szProcName]C.char
+	r := C.nvmlSystemGetProcessName(C.uint(pid), &name[0], szProcName)
+	return C.GoString(&name[0]), errorString(r)
+}
+
+// DeviceCount returns the number of nvidia devices on the system.
+func (nvmlBackend) DeviceCount() (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlDeviceGetCount(&n)
+	return uint(n), errorString(r)
+}
+
+// DeviceHandleByIndex returns the device handle for a particular index.
+// The indices range from 0 to DeviceCount()-1. The order in which NVML
+// enumerates devices has no guarantees of consistency between reboots.
+func (nvmlBackend) DeviceHandleByIndex(idx uint) (DeviceBackend, error) {
+	if C.nvmlHandle == nil {
+		return nil, errLibraryNotLoaded
+	}
+	var dev C.nvmlDevice_t
+	r := C.nvmlDeviceGetHandleByIndex(C.uint(idx), &dev)
+	return nvmlDevice{dev}, errorString(r)
+}
+
+// DeviceHandleByPciBusID returns the device handle for the device with the
+// given PCI bus ID, in the same format as PciInfo.BusID.
+// Unlike indices, PCI bus IDs are stable across GPU removal and rediscovery,
+// so this can be used to re-establish a handle after DiscoverGPUs().
+func (nvmlBackend) DeviceHandleByPciBusID(busID string) (DeviceBackend, error) {
+	if C.nvmlHandle == nil {
+		return nil, errLibraryNotLoaded
+	}
+	cBusID := C.CString(busID)
+	defer C.free(unsafe.Pointer(cBusID))
+	var dev C.nvmlDevice_t
+	r := C.nvmlDeviceGetHandleByPciBusId(cBusID, &dev)
+	return nvmlDevice{dev}, errorString(r)
+}
+
+// MinorNumber returns the minor number for the device.
+// The minor number for the device is such that the Nvidia device node
+// file for each GPU will have the form /dev/nvidia[minor number].
+func (d nvmlDevice) MinorNumber() (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlDeviceGetMinorNumber(d.dev, &n)
+	return uint(n), errorString(r)
+}
+
+// UUID returns the globally unique immutable UUID associated with this device.
+func (d nvmlDevice) UUID() (string, error) {
+	if C.nvmlHandle == nil {
+		return "", errLibraryNotLoaded
+	}
+	var uuid [szUUID]C.char
+	r := C.nvmlDeviceGetUUID(d.dev, &uuid[0], szUUID)
+	return C.GoString(&uuid[0]), errorString(r)
+}
+
+// Name returns the product name of the device.
+func (d nvmlDevice) Name() (string, error) {
+	if C.nvmlHandle == nil {
+		return "", errLibraryNotLoaded
+	}
+	var name [szName]C.char
+	r := C.nvmlDeviceGetName(d.dev, &name[0], szName)
+	return C.GoString(&name[0]), errorString(r)
+}
+
+// MemoryInfo returns the total and used memory (in bytes) of the device.
+func (d nvmlDevice) MemoryInfo() (uint64, uint64, error) {
+	if C.nvmlHandle == nil {
+		return 0, 0, errLibraryNotLoaded
+	}
+	var memory C.nvmlMemory_t
+	r := C.nvmlDeviceGetMemoryInfo(d.dev, &memory)
+	return uint64(memory.total), uint64(memory.used), errorString(r)
+}
+
+// UtilizationRates returns the percent of time over the past sample period during which:
+// utilization.gpu: one or more kernels were executing on the GPU.
+// utilization.memory: global (device) memory was being read or written.
+func (d nvmlDevice) UtilizationRates() (uint, uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, 0, errLibraryNotLoaded
+	}
+	var utilization C.nvmlUtilization_t
+	r := C.nvmlDeviceGetUtilizationRates(d.dev, &utilization)
+	return uint(utilization.gpu), uint(utilization.memory), errorString(r)
+}
+
+// PowerUsage returns the power usage for this GPU and its associated circuitry
+// in milliwatts. The reading is accurate to within +/- 5% of current power draw.
+func (d nvmlDevice) PowerUsage() (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlDeviceGetPowerUsage(d.dev, &n)
+	return uint(n), errorString(r)
+}
+
+// Samples returns the samples of the given type that the driver collected in
+// the last `since` duration, oldest first.
+// The driver keeps a limited buffer of samples per type, e.g. ~16s worth of
+// GPU utilization samples, so a long `since` does not return older data.
+func (d nvmlDevice) Samples(samplingType SamplingType, since time.Duration) ([]Sample, error) {
+	if C.nvmlHandle == nil {
+		return nil, errLibraryNotLoaded
+	}
+	lastTs := C.ulonglong(time.Now().Add(-1*since).UnixNano() / 1000)
+	// Invoking this method with samples set to NULL sets sampleCount to the
+	// number of samples that can be queried.
+	var valType C.nvmlValueType_t
+	var n C.uint
+	r := C.nvmlDeviceGetSamples(d.dev, C.nvmlSamplingType_t(samplingType), lastTs, &valType, &n, nil)
+	if r == C.NVML_ERROR_NOT_FOUND {
+		return nil, nil
+	}
+	if r != C.NVML_SUCCESS || n == 0 {
+		return nil, errorString(r)
+	}
+	samples := make([]C.nvmlSample_t, n)
+	r = C.nvmlDeviceGetSamples(d.dev, C.nvmlSamplingType_t(samplingType), lastTs, &valType, &n, &samples[0])
+	if r == C.NVML_ERROR_NOT_FOUND {
+		return nil, nil
+	}
+	if r != C.NVML_SUCCESS {
+		return nil, errorString(r)
+	}
+	result := make([]Sample, n)
+	for i, sample := range samples[:n] {
+		value, err := sampleValue(valType, sample.sampleValue)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = Sample{
+			Time:  timeFromMicros(sample.timeStamp),
+			Value: value,
+		}
+	}
+	return result, nil
+}
+
+// Temperature returns the temperature for this GPU in Celsius.
+func (d nvmlDevice) Temperature() (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlDeviceGetTemperature(d.dev, C.NVML_TEMPERATURE_GPU, &n)
+	return uint(n), errorString(r)
+}
+
+// FanSpeed returns the temperature for this GPU in the percentage of its full
+// speed, with 100 being the maximum.
+func (d nvmlDevice) FanSpeed() (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlDeviceGetFanSpeed(d.dev, &n)
+	return uint(n), errorString(r)
+}
+
+// EncoderUtilization returns the percent of time over the last sample period during which the GPU video encoder was being used.
+// The sampling period is variable and is returned in the second return argument in microseconds.
+func (d nvmlDevice) EncoderUtilization() (uint, uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	var sp C.uint
+	r := C.nvmlDeviceGetEncoderUtilization(d.dev, &n, &sp)
+	return uint(n), uint(sp), errorString(r)
+}
+
+// DecoderUtilization returns the percent of time over the last sample period during which the GPU video decoder was being used.
+// The sampling period is variable and is returned in the second return argument in microseconds.
+func (d nvmlDevice) DecoderUtilization() (uint, uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	var sp C.uint
+	r := C.nvmlDeviceGetDecoderUtilization(d.dev, &n, &sp)
+	return uint(n), uint(sp), errorString(r)
+}
+
+// VbiosVersion returns the VBIOS version of the device.
+func (d nvmlDevice) VbiosVersion() (string, error) {
+	if C.nvmlHandle == nil {
+		return "", errLibraryNotLoaded
+	}
+	var version [szVbios]C.char
+	r := C.nvmlDeviceGetVbiosVersion(d.dev, &version[0], szVbios)
+	return C.GoString(&version[0]), errorString(r)
+}
+
+// InforomImageVersion returns the global InfoROM image version of the
+// device. Like the VBIOS version, it identifies the firmware the board was
+// flashed with.
+func (d nvmlDevice) InforomImageVersion() (string, error) {
+	if C.nvmlHandle == nil {
+		return "", errLibraryNotLoaded
+	}
+	var version [szInforom]C.char
+	r := C.nvmlDeviceGetInforomImageVersion(d.dev, &version[0], szInforom)
+	return C.GoString(&version[0]), errorString(r)
+}
+
+// Serial returns the globally unique board serial number associated with this
+// device's board. It matches the serial number tag physically attached to the
+// board.
+func (d nvmlDevice) Serial() (string, error) {
+	if C.nvmlHandle == nil {
+		return "", errLibraryNotLoaded
+	}
+	var serial [szSerial]C.char
+	r := C.nvmlDeviceGetSerial(d.dev, &serial[0], szSerial)
+	return C.GoString(&serial[0]), errorString(r)
+}
+
+// BoardPartNumber returns the part number of the board the device is on.
+func (d nvmlDevice) BoardPartNumber() (string, error) {
+	if C.nvmlHandle == nil {
+		return "", errLibraryNotLoaded
+	}
+	var partNumber [szPartNumber]C.char
+	r := C.nvmlDeviceGetBoardPartNumber(d.dev, &partNumber[0], szPartNumber)
+	return C.GoString(&partNumber[0]), errorString(r)
+}
+
+// Brand returns the brand of the device.
+func (d nvmlDevice) Brand() (BrandType, error) {
+	if C.nvmlHandle == nil {
+		return BrandUnknown, errLibraryNotLoaded
+	}
+	var brand C.nvmlBrandType_t
+	r := C.nvmlDeviceGetBrand(d.dev, &brand)
+	return BrandType(brand), errorString(r)
+}
+
+// BoardID returns the board ID of the device.
+// Devices on the same multi-GPU board share the same board ID.
+func (d nvmlDevice) BoardID() (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlDeviceGetBoardId(d.dev, &n)
+	return uint(n), errorString(r)
+}
+
+// MultiGPUBoard returns whether the device is on a multi-GPU board.
+func (d nvmlDevice) MultiGPUBoard() (bool, error) {
+	if C.nvmlHandle == nil {
+		return false, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlDeviceGetMultiGpuBoard(d.dev, &n)
+	return n != 0, errorString(r)
+}
+
+// Index returns the NVML index of the device. This is the index that can be
+// passed to DeviceHandleByIndex() to get the handle for this device.
+func (d nvmlDevice) Index() (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlDeviceGetIndex(d.dev, &n)
+	return uint(n), errorString(r)
+}
+
+// BridgeChipInfo returns the hierarchy of bridge chips on the board the device
+// is on. The bridge immediately above the device is at index 0, its parent at
+// index 1 and so forth.
+// Only applicable to multi-GPU boards.
+func (d nvmlDevice) BridgeChipInfo() ([]BridgeChip, error) {
+	if C.nvmlHandle == nil {
+		return nil, errLibraryNotLoaded
+	}
+	var hierarchy C.nvmlBridgeChipHierarchy_t
+	r := C.nvmlDeviceGetBridgeChipInfo(d.dev, &hierarchy)
+	if r != C.NVML_SUCCESS {
+		return nil, errorString(r)
+	}
+	n := int(hierarchy.bridgeCount)
+	if n > len(hierarchy.bridgeChipInfo) {
+		n = len(hierarchy.bridgeChipInfo)
+	}
+	chips := make([]BridgeChip, n)
+	for i := range chips {
+		info := hierarchy.bridgeChipInfo[i]
+		chips[i] = BridgeChip{
+			Type:            BridgeChipType(info._type),
+			FirmwareVersion: uint(info.fwVersion),
+		}
+	}
+	return chips, nil
+}
+
+// ComputeMode returns the current compute mode of the device. The compute mode
+// determines whether multiple processes can create compute contexts on the
+// device at the same time.
+func (d nvmlDevice) ComputeMode() (ComputeMode, error) {
+	if C.nvmlHandle == nil {
+		return ComputeModeDefault, errLibraryNotLoaded
+	}
+	var mode C.nvmlComputeMode_t
+	r := C.nvmlDeviceGetComputeMode(d.dev, &mode)
+	return ComputeMode(mode), errorString(r)
+}
+
+// PersistenceMode returns whether persistence mode is enabled for the device.
+// When enabled the driver remains loaded even when no active clients exist.
+// Only available on Linux.
+func (d nvmlDevice) PersistenceMode() (bool, error) {
+	if C.nvmlHandle == nil {
+		return false, errLibraryNotLoaded
+	}
+	var mode C.nvmlEnableState_t
+	r := C.nvmlDeviceGetPersistenceMode(d.dev, &mode)
+	return mode == C.NVML_FEATURE_ENABLED, errorString(r)
+}
+
+// DisplayMode returns whether a physical display is currently connected to
+// any of the device's connectors.
+func (d nvmlDevice) DisplayMode() (bool, error) {
+	if C.nvmlHandle == nil {
+		return false, errLibraryNotLoaded
+	}
+	var mode C.nvmlEnableState_t
+	r := C.nvmlDeviceGetDisplayMode(d.dev, &mode)
+	return mode == C.NVML_FEATURE_ENABLED, errorString(r)
+}
+
+// DisplayActive returns whether a display is initialized on the device, i.e.
+// whether memory is allocated on the device for display. A display can be
+// active even when no monitor is physically attached.
+func (d nvmlDevice) DisplayActive() (bool, error) {
+	if C.nvmlHandle == nil {
+		return false, errLibraryNotLoaded
+	}
+	var active C.nvmlEnableState_t
+	r := C.nvmlDeviceGetDisplayActive(d.dev, &active)
+	return active == C.NVML_FEATURE_ENABLED, errorString(r)
+}
+
+// CudaComputeCapability returns the major and minor CUDA compute capability of
+// the device.
+func (d nvmlDevice) CudaComputeCapability() (int, int, error) {
+	if C.nvmlHandle == nil {
+		return 0, 0, errLibraryNotLoaded
+	}
+	var major, minor C.int
+	r := C.nvmlDeviceGetCudaComputeCapability(d.dev, &major, &minor)
+	return int(major), int(minor), errorString(r)
+}
+
+// TemperatureThreshold returns the temperature threshold of the given type for
+// this GPU in Celsius.
+func (d nvmlDevice) TemperatureThreshold(threshold TemperatureThreshold) (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlDeviceGetTemperatureThreshold(d.dev, C.nvmlTemperatureThresholds_t(threshold), &n)
+	return uint(n), errorString(r)
+}
+
+// EncoderCapacity returns the current capacity of the device's encoder of the
+// given type, in macroblocks per second.
+func (d nvmlDevice) EncoderCapacity(queryType EncoderType) (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlDeviceGetEncoderCapacity(d.dev, C.nvmlEncoderType_t(queryType), &n)
+	return uint(n), errorString(r)
+}
+
+// EncoderStats returns the number of active encoder sessions, the trailing
+// average FPS of all active sessions and the average encode latency in
+// microseconds.
+func (d nvmlDevice) EncoderStats() (uint, uint, uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, 0, 0, errLibraryNotLoaded
+	}
+	var sessionCount, averageFps, averageLatency C.uint
+	r := C.nvmlDeviceGetEncoderStats(d.dev, &sessionCount, &averageFps, &averageLatency)
+	return uint(sessionCount), uint(averageFps), uint(averageLatency), errorString(r)
+}
+
+// EncoderSessions returns information about the active encoder sessions on
+// the device.
+func (d nvmlDevice) EncoderSessions() ([]EncoderSession, error) {
+	return encoderSessions(func(n *C.uint, infos *C.nvmlEncoderSessionInfo_t) C.nvmlReturn_t {
+		return C.nvmlDeviceGetEncoderSessions(d.dev, n, infos)
+	})
+}
+
+// encoderSessions returns the encoder sessions listed by get, which is either
+// nvmlDeviceGetEncoderSessions or nvmlVgpuInstanceGetEncoderSessions.
+func encoderSessions(get func(*C.uint, *C.nvmlEncoderSessionInfo_t) C.nvmlReturn_t) ([]EncoderSession, error) {
+	if C.nvmlHandle == nil {
+		return nil, errLibraryNotLoaded
+	}
+	// Invoking get with the count set to 0 returns the number of active
+	// sessions in the count.
+	var n C.uint
+	r := get(&n, nil)
+	if r != C.NVML_SUCCESS || n == 0 {
+		return nil, errorString(r)
+	}
+	infos := make([]C.nvmlEncoderSessionInfo_t, n)
+	r = get(&n, &infos[0])
+	if r != C.NVML_SUCCESS {
+		return nil, errorString(r)
+	}
+	sessions := make([]EncoderSession, n)
+	for i, info := range infos[:n] {
+		sessions[i] = EncoderSession{
+			SessionID:      uint(info.sessionId),
+			PID:            uint(info.pid),
+			VgpuInstanceID: uint(info.vgpuInstance),
+			Codec:          EncoderType(info.codecType),
+			HResolution:    uint(info.hResolution),
+			VResolution:    uint(info.vResolution),
+			AverageFPS:     uint(info.averageFps),
+			AverageLatency: uint(info.averageLatency),
+		}
+	}
+	return sessions, nil
+}
+
+// UnitCount returns the number of S-class units on the system.
+func (nvmlBackend) UnitCount() (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlUnitGetCount(&n)
+	return uint(n), errorString(r)
+}
+
+type nvmlUnit struct {
+	unit C.nvmlUnit_t
+}
+
+// UnitHandleByIndex returns the unit handle for a particular index.
+// The indices range from 0 to UnitCount()-1. The order in which NVML
+// enumerates units has no guarantees of consistency between reboots.
+func (nvmlBackend) UnitHandleByIndex(idx uint) (UnitBackend, error) {
+	if C.nvmlHandle == nil {
+		return nil, errLibraryNotLoaded
+	}
+	var unit C.nvmlUnit_t
+	r := C.nvmlUnitGetHandleByIndex(C.uint(idx), &unit)
+	return nvmlUnit{unit}, errorString(r)
+}
+
+// Info returns the static information associated with the unit.
+func (u nvmlUnit) Info() (UnitInfo, error) {
+	if C.nvmlHandle == nil {
+		return UnitInfo{}, errLibraryNotLoaded
+	}
+	var info C.nvmlUnitInfo_t
+	r := C.nvmlUnitGetUnitInfo(u.unit, &info)
+	if r != C.NVML_SUCCESS {
+		return UnitInfo{}, errorString(r)
+	}
+	return UnitInfo{
+		Name:            C.GoString(&info.name[0]),
+		ID:              C.GoString(&info.id[0]),
+		Serial:          C.GoString(&info.serial[0]),
+		FirmwareVersion: C.GoString(&info.firmwareVersion[0]),
+	}, nil
+}
+
+// PsuInfo returns the state and readings of the unit's power supply.
+func (u nvmlUnit) PsuInfo() (PsuInfo, error) {
+	if C.nvmlHandle == nil {
+		return PsuInfo{}, errLibraryNotLoaded
+	}
+	var psu C.nvmlPSUInfo_t
+	r := C.nvmlUnitGetPsuInfo(u.unit, &psu)
+	if r != C.NVML_SUCCESS {
+		return PsuInfo{}, errorString(r)
+	}
+	return PsuInfo{
+		State:   C.GoString(&psu.state[0]),
+		Current: uint(psu.current),
+		Voltage: uint(psu.voltage),
+		Power:   uint(psu.power),
+	}, nil
+}
+
+// FanSpeeds returns the speed and state of each fan in the unit.
+func (u nvmlUnit) FanSpeeds() ([]UnitFan, error) {
+	if C.nvmlHandle == nil {
+		return nil, errLibraryNotLoaded
+	}
+	var speeds C.nvmlUnitFanSpeeds_t
+	r := C.nvmlUnitGetFanSpeedInfo(u.unit, &speeds)
+	if r != C.NVML_SUCCESS {
+		return nil, errorString(r)
+	}
+	n := int(speeds.count)
+	if n > len(speeds.fans) {
+		n = len(speeds.fans)
+	}
+	fans := make([]UnitFan, n)
+	for i := range fans {
+		fans[i] = UnitFan{
+			Speed: uint(speeds.fans[i].speed),
+			State: FanState(speeds.fans[i].state),
+		}
+	}
+	return fans, nil
+}
+
+// Temperature returns the temperature reading of the given type for the unit
+// in Celsius. Not every unit provides every type of reading.
+func (u nvmlUnit) Temperature(temperatureType UnitTemperatureType) (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlUnitGetTemperature(u.unit, C.uint(temperatureType), &n)
+	return uint(n), errorString(r)
+}
+
+// LedState returns the color of the unit's LED and, if it is amber, the cause.
+func (u nvmlUnit) LedState() (LedState, error) {
+	if C.nvmlHandle == nil {
+		return LedState{}, errLibraryNotLoaded
+	}
+	var state C.nvmlLedState_t
+	r := C.nvmlUnitGetLedState(u.unit, &state)
+	if r != C.NVML_SUCCESS {
+		return LedState{}, errorString(r)
+	}
+	return LedState{
+		Color: LedColor(state.color),
+		Cause: C.GoString(&state.cause[0]),
+	}, nil
+}
+
+// Devices returns the handles of the devices attached to the unit.
+func (u nvmlUnit) Devices() ([]DeviceBackend, error) {
+	if C.nvmlHandle == nil {
+		return nil, errLibraryNotLoaded
+	}
+	// A unit can't have more devices attached than there are on the system.
+	var n C.uint
+	r := C.nvmlDeviceGetCount(&n)
+	if r != C.NVML_SUCCESS || n == 0 {
+		return nil, errorString(r)
+	}
+	devs := make([]C.nvmlDevice_t, n)
+	r = C.nvmlUnitGetDevices(u.unit, &n, &devs[0])
+	if r != C.NVML_SUCCESS {
+		return nil, errorString(r)
+	}
+	devices := make([]DeviceBackend, n)
+	for i := range devices {
+		devices[i] = nvmlDevice{devs[i]}
+	}
+	return devices, nil
+}
+
+// SupportedVgpus returns the vGPU types supported by the device.
+func (d nvmlDevice) SupportedVgpus() ([]VgpuType, error) {
+	return d.vgpuTypes(func(n *C.uint, ids *C.nvmlVgpuTypeId_t) C.nvmlReturn_t {
+		return C.nvmlDeviceGetSupportedVgpus(d.dev, n, ids)
+	})
+}
+
+// CreatableVgpus returns the vGPU types that can currently be created on the
+// device. This may differ over time, e.g. if the device only allows one vGPU
+// type to run at a time, only the type that is already running is creatable.
+func (d nvmlDevice) CreatableVgpus() ([]VgpuType, error) {
+	return d.vgpuTypes(func(n *C.uint, ids *C.nvmlVgpuTypeId_t) C.nvmlReturn_t {
+		return C.nvmlDeviceGetCreatableVgpus(d.dev, n, ids)
+	})
+}
+
+// vgpuTypes returns the vGPU types listed by get, which is either
+// nvmlDeviceGetSupportedVgpus or nvmlDeviceGetCreatableVgpus.
+func (d nvmlDevice) vgpuTypes(get func(*C.uint, *C.nvmlVgpuTypeId_t) C.nvmlReturn_t) ([]VgpuType, error) {
+	if C.nvmlHandle == nil {
+		return nil, errLibraryNotLoaded
+	}
+	// Invoking get with the count set to 0 returns NVML_SUCCESS if there are
+	// no vGPU types and NVML_ERROR_INSUFFICIENT_SIZE along with the count
+	// otherwise.
+	var n C.uint
+	r := get(&n, nil)
+	if r == C.NVML_SUCCESS {
+		return nil, nil
+	}
+	if r != C.NVML_ERROR_INSUFFICIENT_SIZE {
+		return nil, errorString(r)
+	}
+	ids := make([]C.nvmlVgpuTypeId_t, n)
+	r = get(&n, &ids[0])
+	if r != C.NVML_SUCCESS {
+		return nil, errorString(r)
+	}
+	types := make([]VgpuType, n)
+	for i := range types {
+		types[i] = VgpuType(ids[i])
+	}
+	return types, nil
+}
+
+// Class returns the class of the vGPU type, e.g. "Compute" or "Quadro".
+func (t nvmlVgpuType) Class() (string, error) {
+	if C.nvmlHandle == nil {
+		return "", errLibraryNotLoaded
+	}
+	var class [szVgpuName]C.char
+	size := C.uint(szVgpuName)
+	r := C.nvmlVgpuTypeGetClass(C.nvmlVgpuTypeId_t(t), &class[0], &size)
+	return C.GoString(&class[0]), errorString(r)
+}
+
+// Name returns the name of the vGPU type, e.g. "GRID M60-2Q".
+func (t nvmlVgpuType) Name() (string, error) {
+	if C.nvmlHandle == nil {
+		return "", errLibraryNotLoaded
+	}
+	var name [szVgpuName]C.char
+	size := C.uint(szVgpuName)
+	r := C.nvmlVgpuTypeGetName(C.nvmlVgpuTypeId_t(t), &name[0], &size)
+	return C.GoString(&name[0]), errorString(r)
+}
+
+// DeviceID returns the PCI device ID and subsystem ID of the vGPU type. Each
+// value contains the vendor ID in the same 32 bit value.
+func (t nvmlVgpuType) DeviceID() (uint64, uint64, error) {
+	if C.nvmlHandle == nil {
+		return 0, 0, errLibraryNotLoaded
+	}
+	var deviceID, subsystemID C.ulonglong
+	r := C.nvmlVgpuTypeGetDeviceID(C.nvmlVgpuTypeId_t(t), &deviceID, &subsystemID)
+	return uint64(deviceID), uint64(subsystemID), errorString(r)
+}
+
+// FramebufferSize returns the framebuffer size of the vGPU type in bytes.
+func (t nvmlVgpuType) FramebufferSize() (uint64, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.ulonglong
+	r := C.nvmlVgpuTypeGetFramebufferSize(C.nvmlVgpuTypeId_t(t), &n)
+	return uint64(n), errorString(r)
+}
+
+// NumDisplayHeads returns the number of display heads supported by the vGPU
+// type.
+func (t nvmlVgpuType) NumDisplayHeads() (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlVgpuTypeGetNumDisplayHeads(C.nvmlVgpuTypeId_t(t), &n)
+	return uint(n), errorString(r)
+}
+
+// Resolution returns the maximum resolution (width and height in pixels)
+// supported by the display head with the given zero-based index.
+func (t nvmlVgpuType) Resolution(displayIndex uint) (uint, uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, 0, errLibraryNotLoaded
+	}
+	var x, y C.uint
+	r := C.nvmlVgpuTypeGetResolution(C.nvmlVgpuTypeId_t(t), C.uint(displayIndex), &x, &y)
+	return uint(x), uint(y), errorString(r)
+}
+
+// License returns the licenses required to run the vGPU type in the form
+// "<license name>,<version>", e.g. "GRID-Virtual-PC,2.0". Multiple licenses
+// are separated by semicolons.
+func (t nvmlVgpuType) License() (string, error) {
+	if C.nvmlHandle == nil {
+		return "", errLibraryNotLoaded
+	}
+	var license [szLicense]C.char
+	r := C.nvmlVgpuTypeGetLicense(C.nvmlVgpuTypeId_t(t), &license[0], szLicense)
+	return C.GoString(&license[0]), errorString(r)
+}
+
+// FrameRateLimit returns the static frame rate limit of the vGPU type.
+// An error is returned if the frame rate limiter is turned off for the type.
+func (t nvmlVgpuType) FrameRateLimit() (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlVgpuTypeGetFrameRateLimit(C.nvmlVgpuTypeId_t(t), &n)
+	return uint(n), errorString(r)
+}
+
+// VgpuTypeMaxInstances returns the maximum number of instances of the vGPU
+// type t that can be created on the device.
+func (d nvmlDevice) VgpuTypeMaxInstances(t VgpuType) (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlVgpuTypeGetMaxInstances(d.dev, C.nvmlVgpuTypeId_t(t), &n)
+	return uint(n), errorString(r)
+}
+
+// ActiveVgpus returns the vGPU instances currently running on the device.
+func (d nvmlDevice) ActiveVgpus() ([]VgpuInstance, error) {
+	if C.nvmlHandle == nil {
+		return nil, errLibraryNotLoaded
+	}
+	// Invoking this method with vgpuCount set to 0 returns NVML_SUCCESS if
+	// there are no instances and NVML_ERROR_INSUFFICIENT_SIZE along with the
+	// count otherwise.
+	var n C.uint
+	r := C.nvmlDeviceGetActiveVgpus(d.dev, &n, nil)
+	if r == C.NVML_SUCCESS {
+		return nil, nil
+	}
+	if r != C.NVML_ERROR_INSUFFICIENT_SIZE {
+		return nil, errorString(r)
+	}
+	ids := make([]C.nvmlVgpuInstance_t, n)
+	r = C.nvmlDeviceGetActiveVgpus(d.dev, &n, &ids[0])
+	if r != C.NVML_SUCCESS {
+		return nil, errorString(r)
+	}
+	instances := make([]VgpuInstance, n)
+	for i := range instances {
+		instances[i] = VgpuInstance(ids[i])
+	}
+	return instances, nil
+}
+
+// VMID returns the ID of the VM the vGPU instance is assigned to. The format
+// of the ID varies by platform and is indicated by the returned VMIDType.
+func (v nvmlVgpuInstance) VMID() (string, VMIDType, error) {
+	if C.nvmlHandle == nil {
+		return "", VMIDDomainID, errLibraryNotLoaded
+	}
+	var id [szUUID]C.char
+	var idType C.nvmlVgpuVmIdType_t
+	r := C.nvmlVgpuInstanceGetVmID(C.nvmlVgpuInstance_t(v), &id[0], szUUID, &idType)
+	return C.GoString(&id[0]), VMIDType(idType), errorString(r)
+}
+
+// UUID returns the globally unique UUID associated with the vGPU instance.
+func (v nvmlVgpuInstance) UUID() (string, error) {
+	if C.nvmlHandle == nil {
+		return "", errLibraryNotLoaded
+	}
+	var uuid [szUUID]C.char
+	r := C.nvmlVgpuInstanceGetUUID(C.nvmlVgpuInstance_t(v), &uuid[0], szUUID)
+	return C.GoString(&uuid[0]), errorString(r)
+}
+
+// VMDriverVersion returns the version of the NVIDIA driver installed in the VM
+// the vGPU instance is assigned to. It is "Unknown" if no driver is loaded in
+// the VM yet.
+func (v nvmlVgpuInstance) VMDriverVersion() (string, error) {
+	if C.nvmlHandle == nil {
+		return "", errLibraryNotLoaded
+	}
+	var version [szDriver]C.char
+	r := C.nvmlVgpuInstanceGetVmDriverVersion(C.nvmlVgpuInstance_t(v), &version[0], szDriver)
+	return C.GoString(&version[0]), errorString(r)
+}
+
+// FramebufferUsage returns the amount of framebuffer memory (in bytes)
+// currently used by the VM the vGPU instance is assigned to.
+func (v nvmlVgpuInstance) FramebufferUsage() (uint64, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.ulonglong
+	r := C.nvmlVgpuInstanceGetFbUsage(C.nvmlVgpuInstance_t(v), &n)
+	return uint64(n), errorString(r)
+}
+
+// LicenseStatus returns whether the vGPU instance is currently licensed.
+func (v nvmlVgpuInstance) LicenseStatus() (bool, error) {
+	if C.nvmlHandle == nil {
+		return false, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlVgpuInstanceGetLicenseStatus(C.nvmlVgpuInstance_t(v), &n)
+	return n != 0, errorString(r)
+}
+
+// Type returns the vGPU type of the vGPU instance.
+func (v nvmlVgpuInstance) Type() (VgpuType, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var id C.nvmlVgpuTypeId_t
+	r := C.nvmlVgpuInstanceGetType(C.nvmlVgpuInstance_t(v), &id)
+	return VgpuType(id), errorString(r)
+}
+
+// FrameRateLimit returns the frame rate limit set for the vGPU instance.
+// An error is returned if the frame rate limiter is turned off.
+func (v nvmlVgpuInstance) FrameRateLimit() (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlVgpuInstanceGetFrameRateLimit(C.nvmlVgpuInstance_t(v), &n)
+	return uint(n), errorString(r)
+}
+
+// EncoderCapacity returns the encoder capacity of the vGPU instance, in
+// macroblocks per second.
+func (v nvmlVgpuInstance) EncoderCapacity() (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlVgpuInstanceGetEncoderCapacity(C.nvmlVgpuInstance_t(v), &n)
+	return uint(n), errorString(r)
+}
+
+// EncoderStats returns the number of active encoder sessions, the trailing
+// average FPS of all active sessions and the average encode latency in
+// microseconds of the vGPU instance.
+func (v nvmlVgpuInstance) EncoderStats() (uint, uint, uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, 0, 0, errLibraryNotLoaded
+	}
+	var sessionCount, averageFps, averageLatency C.uint
+	r := C.nvmlVgpuInstanceGetEncoderStats(C.nvmlVgpuInstance_t(v), &sessionCount, &averageFps, &averageLatency)
+	return uint(sessionCount), uint(averageFps), uint(averageLatency), errorString(r)
+}
 
-  // Allocate memory to store sampleCount samples.
-  // In my experiments, the sampleCount at this stage was always 120 for
-  // NVML_TOTAL_POWER_SAMPLES and 100 for NVML_GPU_UTILIZATION_SAMPLES
-  nvmlSample_t* samples = (nvmlSample_t*) malloc(sampleCount * sizeof(nvmlSample_t));
+// EncoderSessions returns information about the active encoder sessions on
+// the vGPU instance.
+func (v nvmlVgpuInstance) EncoderSessions() ([]EncoderSession, error) {
+	return encoderSessions(func(n *C.uint, infos *C.nvmlEncoderSessionInfo_t) C.nvmlReturn_t {
+		return C.nvmlVgpuInstanceGetEncoderSessions(C.nvmlVgpuInstance_t(v), n, infos)
+	})
+}
 
-  r = nvmlDeviceGetSamplesFunc(device, type, lastSeenTimeStamp, &sampleValType, &sampleCount, samples);
-  if (r != NVML_SUCCESS) {
-    free(samples);
-    return r;
-  }
+// VgpuUtilization returns the SM, framebuffer, encoder and decoder utilization
+// of each vGPU instance running on the device, using the samples collected in
+// the last `since` duration. There is one sample per vGPU instance.
+func (d nvmlDevice) VgpuUtilization(since time.Duration) ([]VgpuUtilizationSample, error) {
+	if C.nvmlHandle == nil {
+		return nil, errLibraryNotLoaded
+	}
+	lastTs := C.ulonglong(time.Now().Add(-1*since).UnixNano() / 1000)
+	// Invoking this method with utilizationSamples set to NULL returns
+	// NVML_SUCCESS if there are no instances and NVML_ERROR_INSUFFICIENT_SIZE
+	// along with the count otherwise.
+	var valType C.nvmlValueType_t
+	var n C.uint
+	r := C.nvmlDeviceGetVgpuUtilization(d.dev, lastTs, &valType, &n, nil)
+	if r == C.NVML_SUCCESS || r == C.NVML_ERROR_NOT_FOUND {
+		return nil, nil
+	}
+	if r != C.NVML_ERROR_INSUFFICIENT_SIZE {
+		return nil, errorString(r)
+	}
+	samples := make([]C.nvmlVgpuInstanceUtilizationSample_t, n)
+	r = C.nvmlDeviceGetVgpuUtilization(d.dev, lastTs, &valType, &n, &samples[0])
+	if r == C.NVML_ERROR_NOT_FOUND {
+		return nil, nil
+	}
+	if r != C.NVML_SUCCESS {
+		return nil, errorString(r)
+	}
+	utilization := make([]VgpuUtilizationSample, n)
+	for i, sample := range samples[:n] {
+		u := &utilization[i]
+		u.Instance = VgpuInstance(sample.vgpuInstance)
+		u.Time = timeFromMicros(sample.timeStamp)
+		values := []struct {
+			dst *uint
+			src C.nvmlValue_t
+		}{
+			{&u.SM, sample.smUtil},
+			{&u.Memory, sample.memUtil},
+			{&u.Encoder, sample.encUtil},
+			{&u.Decoder, sample.decUtil},
+		}
+		for _, v := range values {
+			value, err := sampleValue(valType, v.src)
+			if err != nil {
+				return nil, err
+			}
+			*v.dst = uint(value)
+		}
+	}
+	return utilization, nil
+}
 
-  int i = 0;
-  unsigned int sum = 0;
-  for (; i < sampleCount; i++) {
-    sum += samples[i].sampleValue.uiVal;
-  }
-  *averageUsage = sum/sampleCount;
+// VgpuProcessUtilization returns the SM, framebuffer, encoder and decoder
+// utilization of the processes running in vGPU instances on the device, using
+// the samples collected in the last `since` duration. Only processes with
+// non-zero utilization are included.
+func (d nvmlDevice) VgpuProcessUtilization(since time.Duration) ([]VgpuProcessUtilizationSample, error) {
+	if C.nvmlHandle == nil {
+		return nil, errLibraryNotLoaded
+	}
+	lastTs := C.ulonglong(time.Now().Add(-1*since).UnixNano() / 1000)
+	// Invoking this method with utilizationSamples set to NULL returns
+	// NVML_ERROR_INSUFFICIENT_SIZE along with the count.
+	var n C.uint
+	r := C.nvmlDeviceGetVgpuProcessUtilization(d.dev, lastTs, &n, nil)
+	if r == C.NVML_ERROR_NOT_FOUND {
+		return nil, nil
+	}
+	if r != C.NVML_SUCCESS && r != C.NVML_ERROR_INSUFFICIENT_SIZE {
+		return nil, errorString(r)
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	samples := make([]C.nvmlVgpuProcessUtilizationSample_t, n)
+	r = C.nvmlDeviceGetVgpuProcessUtilization(d.dev, lastTs, &n, &samples[0])
+	if r == C.NVML_ERROR_NOT_FOUND {
+		return nil, nil
+	}
+	if r != C.NVML_SUCCESS {
+		return nil, errorString(r)
+	}
+	utilization := make([]VgpuProcessUtilizationSample, n)
+	for i, sample := range samples[:n] {
+		utilization[i] = VgpuProcessUtilizationSample{
+			Instance:    VgpuInstance(sample.vgpuInstance),
+			PID:         uint(sample.pid),
+			ProcessName: C.GoString(&sample.processName[0]),
+			Time:        timeFromMicros(sample.timeStamp),
+			SM:          uint(sample.smUtil),
+			Memory:      uint(sample.memUtil),
+			Encoder:     uint(sample.encUtil),
+			Decoder:     uint(sample.decUtil),
+		}
+	}
+	return utilization, nil
+}
 
-  free(samples);
-  return r;
+// timeFromMicros converts a CPU timestamp returned by NVML (Unix epoch in
+// micro seconds) into a time.Time.
+func timeFromMicros(ts C.ulonglong) time.Time {
+	return time.Unix(0, int64(ts)*1000)
 }
-*/
-import "C"
 
-import (
-	"errors"
-	"fmt"
-	"time"
-)
+// sampleValue returns the member of a nvmlValue_t union indicated by
+// valueType as a float64.
+// cgo represents unions as byte arrays, so the member has to be read through
+// a pointer conversion.
+func sampleValue(valueType C.nvmlValueType_t, v C.nvmlValue_t) (float64, error) {
+	p := unsafe.Pointer(&v[0])
+	switch valueType {
+	case C.NVML_VALUE_TYPE_DOUBLE:
+		return float64(*(*C.double)(p)), nil
+	case C.NVML_VALUE_TYPE_UNSIGNED_INT:
+		return float64(*(*C.uint)(p)), nil
+	case C.NVML_VALUE_TYPE_UNSIGNED_LONG:
+		return float64(*(*C.ulong)(p)), nil
+	case C.NVML_VALUE_TYPE_UNSIGNED_LONG_LONG:
+		return float64(*(*C.ulonglong)(p)), nil
+	case C.NVML_VALUE_TYPE_SIGNED_LONG_LONG:
+		return float64(*(*C.longlong)(p)), nil
+	}
+	return 0, fmt.Errorf("nvml: unknown sample value type %d", valueType)
+}
 
-const (
-	szDriver = C.NVML_SYSTEM_DRIVER_VERSION_BUFFER_SIZE
-	szName   = C.NVML_DEVICE_NAME_BUFFER_SIZE
-	szUUID   = C.NVML_DEVICE_UUID_BUFFER_SIZE
-)
+// GridLicensableFeatures returns whether the system supports GRID software
+// licensing and, if it does, the licensable features and their current
+// license state.
+func (d nvmlDevice) GridLicensableFeatures() (bool, []GridLicensableFeature, error) {
+	if C.nvmlHandle == nil {
+		return false, nil, errLibraryNotLoaded
+	}
+	var features C.nvmlGridLicensableFeatures_t
+	r := C.nvmlDeviceGetGridLicensableFeatures(d.dev, &features)
+	if r != C.NVML_SUCCESS {
+		return false, nil, errorString(r)
+	}
+	if features.isGridLicenseSupported == 0 {
+		return false, nil, nil
+	}
+	n := int(features.licensableFeaturesCount)
+	if n > len(features.gridLicensableFeatures) {
+		n = len(features.gridLicensableFeatures)
+	}
+	result := make([]GridLicensableFeature, n)
+	for i := range result {
+		feature := &features.gridLicensableFeatures[i]
+		result[i] = GridLicensableFeature{
+			Code:     GridLicenseFeatureCode(feature.featureCode),
+			Licensed: feature.featureState != 0,
+			Info:     C.GoString(&feature.licenseInfo[0]),
+		}
+	}
+	return true, result, nil
+}
 
-var errLibraryNotLoaded = errors.New("could not load NVML library")
+// PciInfo returns the PCI attributes of the device.
+func (d nvmlDevice) PciInfo() (PciInfo, error) {
+	if C.nvmlHandle == nil {
+		return PciInfo{}, errLibraryNotLoaded
+	}
+	var pci C.nvmlPciInfo_t
+	r := C.nvmlDeviceGetPciInfo(d.dev, &pci)
+	if r != C.NVML_SUCCESS {
+		return PciInfo{}, errorString(r)
+	}
+	return PciInfo{
+		BusID:          C.GoString(&pci.busId[0]),
+		Domain:         uint(pci.domain),
+		Bus:            uint(pci.bus),
+		Device:         uint(pci.device),
+		PciDeviceID:    uint(pci.pciDeviceId),
+		PciSubSystemID: uint(pci.pciSubSystemId),
+	}, nil
+}
 
-// Initialize initializes NVML.
-// Call this before calling any other methods.
-func Initialize() error {
-	return errorString(C.nvmlInit_dl())
+// cPciInfo converts p into a nvmlPciInfo_t that can be passed to NVML.
+func (p PciInfo) cPciInfo() C.nvmlPciInfo_t {
+	var pci C.nvmlPciInfo_t
+	for i := 0; i < len(p.BusID) && i < len(pci.busId)-1; i++ {
+		pci.busId[i] = C.char(p.BusID[i])
+	}
+	pci.domain = C.uint(p.Domain)
+	pci.bus = C.uint(p.Bus)
+	pci.device = C.uint(p.Device)
+	pci.pciDeviceId = C.uint(p.PciDeviceID)
+	pci.pciSubSystemId = C.uint(p.PciSubSystemID)
+	return pci
 }
 
-// Shutdown shuts down NVML.
-// Call this once NVML is no longer being used.
-func Shutdown() error {
-	return errorString(C.nvmlShutdown_dl())
+// QueryDrainState returns whether the GPU at the given PCI address is
+// draining, i.e. no longer accepting new incoming requests.
+// Only available on Linux.
+func (nvmlBackend) QueryDrainState(pci PciInfo) (EnableState, error) {
+	if C.nvmlHandle == nil {
+		return Disabled, errLibraryNotLoaded
+	}
+	cPci := pci.cPciInfo()
+	var state C.nvmlEnableState_t
+	r := C.nvmlDeviceQueryDrainState(&cPci, &state)
+	return EnableState(state), errorString(r)
 }
 
-// errorString takes a nvmlReturn_t and converts it into a golang error.
-// It uses a nvml method to convert to a user friendly error message.
-func errorString(ret C.nvmlReturn_t) error {
-	if ret == C.NVML_SUCCESS {
-		return nil
+// ModifyDrainState puts the GPU at the given PCI address into or out of the
+// draining state. A draining GPU no longer accepts new incoming requests and
+// is not visible to new NVML processes.
+// Persistence mode must be disabled for the GPU before calling this.
+// Requires root. Only available on Linux.
+func (nvmlBackend) ModifyDrainState(pci PciInfo, state EnableState) error {
+	if C.nvmlHandle == nil {
+		return errLibraryNotLoaded
 	}
-	// We need to special case this because if nvml library is not found
-	// nvmlErrorString() method will not work.
-	if ret == C.NVML_ERROR_LIBRARY_NOT_FOUND || C.nvmlHandle == nil {
+	cPci := pci.cPciInfo()
+	r := C.nvmlDeviceModifyDrainState(&cPci, C.nvmlEnableState_t(state))
+	return errorString(r)
+}
+
+// RemoveGPU removes the GPU at the given PCI address from the view of both
+// NVML and the kernel driver, provided no other processes are attached to it.
+// The GPU should have been put into the draining state by ModifyDrainState()
+// first.
+//
+// This changes the enumeration of devices: DeviceCount() and
+// DeviceHandleByIndex() reflect the removal, and handles for devices after the
+// removed one are no longer valid and must be re-established.
+// Requires root. Only available on Linux.
+func (nvmlBackend) RemoveGPU(pci PciInfo) error {
+	if C.nvmlHandle == nil {
 		return errLibraryNotLoaded
 	}
-	err := C.GoString(C.nvmlErrorString(ret))
-	return fmt.Errorf("nvml: %v", err)
+	cPci := pci.cPciInfo()
+	r := C.nvmlDeviceRemoveGpu(&cPci)
+	return errorString(r)
 }
 
-// SystemDriverVersion returns the the driver version on the system.
-func SystemDriverVersion() (string, error) {
+// DiscoverGPUs asks the OS and the kernel driver to rediscover previously
+// removed GPUs in the portion of the PCI tree given by the Domain, Bus and
+// Device fields of pci. If all of them are zero the entire tree is searched.
+//
+// Newly discovered GPUs are initialized and have their ECC scrubbed, which may
+// take several seconds per GPU. Afterwards the enumeration of devices may have
+// changed and no previously obtained handle is guaranteed to be valid; use
+// DeviceCount() and DeviceHandleByIndex() or DeviceHandleByPciBusID() to
+// obtain new ones.
+// Requires root. Only available on Linux.
+func (nvmlBackend) DiscoverGPUs(pci PciInfo) error {
 	if C.nvmlHandle == nil {
-		return "", errLibraryNotLoaded
+		return errLibraryNotLoaded
 	}
-	var driver [szDriver]C.char
-	r := C.nvmlSystemGetDriverVersion(&driver[0], szDriver)
-	return C.GoString(&driver[0]), errorString(r)
+	cPci := pci.cPciInfo()
+	r := C.nvmlDeviceDiscoverGpus(&cPci)
+	return errorString(r)
 }
 
-// DeviceCount returns the number of nvidia devices on the system.
-func DeviceCount() (uint, error) {
+// CurrPcieLinkGeneration returns the current PCIe link generation of the
+// device. It may be lower than MaxPcieLinkGeneration() while the device is
+// idle due to power saving.
+func (d nvmlDevice) CurrPcieLinkGeneration() (uint, error) {
 	if C.nvmlHandle == nil {
 		return 0, errLibraryNotLoaded
 	}
 	var n C.uint
-	r := C.nvmlDeviceGetCount(&n)
+	r := C.nvmlDeviceGetCurrPcieLinkGeneration(d.dev, &n)
 	return uint(n), errorString(r)
 }
 
-// Device is the handle for the device.
-// This handle is obtained by calling DeviceHandleByIndex().
-type Device struct {
-	dev C.nvmlDevice_t
+// MaxPcieLinkGeneration returns the maximum PCIe link generation possible
+// with this device and system, e.g. 2 for a Gen3 device in a Gen2 system.
+func (d nvmlDevice) MaxPcieLinkGeneration() (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlDeviceGetMaxPcieLinkGeneration(d.dev, &n)
+	return uint(n), errorString(r)
 }
 
-// DeviceHandleByIndex returns the device handle for a particular index.
-// The indices range from 0 to DeviceCount()-1. The order in which NVML
-// enumerates devices has no guarantees of consistency between reboots.
-func DeviceHandleByIndex(idx uint) (Device, error) {
+// CurrPcieLinkWidth returns the current PCIe link width (number of lanes) of
+// the device.
+func (d nvmlDevice) CurrPcieLinkWidth() (uint, error) {
 	if C.nvmlHandle == nil {
-		return Device{}, errLibraryNotLoaded
+		return 0, errLibraryNotLoaded
 	}
-	var dev C.nvmlDevice_t
-	r := C.nvmlDeviceGetHandleByIndex(C.uint(idx), &dev)
-	return Device{dev}, errorString(r)
+	var n C.uint
+	r := C.nvmlDeviceGetCurrPcieLinkWidth(d.dev, &n)
+	return uint(n), errorString(r)
 }
 
-// MinorNumber returns the minor number for the device.
-// The minor number for the device is such that the Nvidia device node
-// file for each GPU will have the form /dev/nvidia[minor number].
-func (d Device) MinorNumber() (uint, error) {
+// MaxPcieLinkWidth returns the maximum PCIe link width (number of lanes)
+// possible with this device and system.
+func (d nvmlDevice) MaxPcieLinkWidth() (uint, error) {
 	if C.nvmlHandle == nil {
 		return 0, errLibraryNotLoaded
 	}
 	var n C.uint
-	r := C.nvmlDeviceGetMinorNumber(d.dev, &n)
+	r := C.nvmlDeviceGetMaxPcieLinkWidth(d.dev, &n)
 	return uint(n), errorString(r)
 }
 
-// UUID returns the globally unique immutable UUID associated with this device.
-func (d Device) UUID() (string, error) {
+// PcieReplayCounter returns the PCIe replay counter of the device. A growing
+// counter indicates PCIe link errors.
+func (d nvmlDevice) PcieReplayCounter() (uint, error) {
 	if C.nvmlHandle == nil {
-		return "", errLibraryNotLoaded
+		return 0, errLibraryNotLoaded
 	}
-	var uuid [szUUID]C.char
-	r := C.nvmlDeviceGetUUID(d.dev, &uuid[0], szUUID)
-	return C.GoString(&uuid[0]), errorString(r)
+	var n C.uint
+	r := C.nvmlDeviceGetPcieReplayCounter(d.dev, &n)
+	return uint(n), errorString(r)
 }
 
-// Name returns the product name of the device.
-func (d Device) Name() (string, error) {
+// PcieThroughput returns the PCIe throughput of the device in KB/s for the
+// given counter. The throughput is measured over a 20ms interval.
+func (d nvmlDevice) PcieThroughput(counter PcieUtilCounter) (uint, error) {
 	if C.nvmlHandle == nil {
-		return "", errLibraryNotLoaded
+		return 0, errLibraryNotLoaded
 	}
-	var name [szName]C.char
-	r := C.nvmlDeviceGetName(d.dev, &name[0], szName)
-	return C.GoString(&name[0]), errorString(r)
+	var n C.uint
+	r := C.nvmlDeviceGetPcieThroughput(d.dev, C.nvmlPcieUtilCounter_t(counter), &n)
+	return uint(n), errorString(r)
 }
 
-// MemoryInfo returns the total and used memory (in bytes) of the device.
-func (d Device) MemoryInfo() (uint64, uint64, error) {
+// DriverModel returns the current and pending driver model of the device.
+// The pending model is the one that will be in effect after the next reboot.
+// Only available on Windows.
+func (d nvmlDevice) DriverModel() (DriverModel, DriverModel, error) {
 	if C.nvmlHandle == nil {
 		return 0, 0, errLibraryNotLoaded
 	}
-	var memory C.nvmlMemory_t
-	r := C.nvmlDeviceGetMemoryInfo(d.dev, &memory)
-	return uint64(memory.total), uint64(memory.used), errorString(r)
+	var current, pending C.nvmlDriverModel_t
+	r := C.nvmlDeviceGetDriverModel(d.dev, &current, &pending)
+	return DriverModel(current), DriverModel(pending), errorString(r)
 }
 
-// UtilizationRates returns the percent of time over the past sample period during which:
-// utilization.gpu: one or more kernels were executing on the GPU.
-// utilization.memory: global (device) memory was being read or written.
-func (d Device) UtilizationRates() (uint, uint, error) {
+// GPUOperationMode returns the current and pending GPU operation mode (GOM)
+// of the device. The pending mode is the one that will be in effect after the
+// next reboot.
+func (d nvmlDevice) GPUOperationMode() (GPUOperationMode, GPUOperationMode, error) {
 	if C.nvmlHandle == nil {
 		return 0, 0, errLibraryNotLoaded
 	}
-	var utilization C.nvmlUtilization_t
-	r := C.nvmlDeviceGetUtilizationRates(d.dev, &utilization)
-	return uint(utilization.gpu), uint(utilization.memory), errorString(r)
+	var current, pending C.nvmlGpuOperationMode_t
+	r := C.nvmlDeviceGetGpuOperationMode(d.dev, &current, &pending)
+	return GPUOperationMode(current), GPUOperationMode(pending), errorString(r)
 }
 
-// PowerUsage returns the power usage for this GPU and its associated circuitry
-// in milliwatts. The reading is accurate to within +/- 5% of current power draw.
-func (d Device) PowerUsage() (uint, error) {
+// VirtualizationMode returns the virtualization mode of the device, i.e.
+// whether it is used bare-metal, passed through to a VM or used for vGPU.
+func (d nvmlDevice) VirtualizationMode() (VirtualizationMode, error) {
 	if C.nvmlHandle == nil {
-		return 0, errLibraryNotLoaded
+		return VirtualizationModeNone, errLibraryNotLoaded
+	}
+	var mode C.nvmlGpuVirtualizationMode_t
+	r := C.nvmlDeviceGetVirtualizationMode(d.dev, &mode)
+	return VirtualizationMode(mode), errorString(r)
+}
+
+// ComputeRunningProcesses returns the compute processes (e.g. CUDA
+// applications) that have a context on the device.
+func (d nvmlDevice) ComputeRunningProcesses() ([]ProcessInfo, error) {
+	return runningProcesses(func(n *C.uint, infos *C.nvmlProcessInfo_t) C.nvmlReturn_t {
+		return C.nvmlDeviceGetComputeRunningProcesses(d.dev, n, infos)
+	})
+}
+
+// GraphicsRunningProcesses returns the graphics processes (e.g. OpenGL
+// applications) that have a context on the device.
+func (d nvmlDevice) GraphicsRunningProcesses() ([]ProcessInfo, error) {
+	return runningProcesses(func(n *C.uint, infos *C.nvmlProcessInfo_t) C.nvmlReturn_t {
+		return C.nvmlDeviceGetGraphicsRunningProcesses(d.dev, n, infos)
+	})
+}
+
+// runningProcesses returns the processes listed by get, which is either
+// nvmlDeviceGetComputeRunningProcesses or
+// nvmlDeviceGetGraphicsRunningProcesses.
+func runningProcesses(get func(*C.uint, *C.nvmlProcessInfo_t) C.nvmlReturn_t) ([]ProcessInfo, error) {
+	if C.nvmlHandle == nil {
+		return nil, errLibraryNotLoaded
 	}
+	// Invoking get with the count set to 0 returns NVML_SUCCESS if there are
+	// no processes and NVML_ERROR_INSUFFICIENT_SIZE along with the count
+	// otherwise. Processes may be started in between the calls, so leave some
+	// room and retry a few times if that wasn't enough.
 	var n C.uint
-	r := C.nvmlDeviceGetPowerUsage(d.dev, &n)
-	return uint(n), errorString(r)
+	r := get(&n, nil)
+	for retry := 0; r == C.NVML_ERROR_INSUFFICIENT_SIZE && retry < 3; retry++ {
+		n += 8
+		infos := make([]C.nvmlProcessInfo_t, n)
+		r = get(&n, &infos[0])
+		if r != C.NVML_SUCCESS {
+			continue
+		}
+		processes := make([]ProcessInfo, n)
+		for i, info := range infos[:n] {
+			processes[i] = ProcessInfo{
+				PID:           uint(info.pid),
+				UsedGPUMemory: uint64(info.usedGpuMemory),
+			}
+		}
+		return processes, nil
+	}
+	return nil, errorString(r)
 }
 
-// AveragePowerUsage returns the power usage for this GPU and its associated circuitry
-// in milliwatts averaged over the samples collected in the last `since` duration.
-func (d Device) AveragePowerUsage(since time.Duration) (uint, error) {
+// SetPersistenceMode enables or disables persistence mode for the device.
+// When enabled the driver remains loaded even when no active clients exist.
+// Requires root. Only available on Linux.
+// ErrNoPermission and ErrNotSupported are returned if the caller isn't
+// allowed to change the mode or the device doesn't support it, respectively.
+func (d nvmlDevice) SetPersistenceMode(enabled bool) error {
+	if C.nvmlHandle == nil {
+		return errLibraryNotLoaded
+	}
+	return errorString(C.nvmlDeviceSetPersistenceMode(d.dev, enableState(enabled)))
+}
+
+// SetComputeMode sets the compute mode of the device.
+// Requires root. The mode does not persist across reboots.
+// ErrNoPermission and ErrNotSupported are returned if the caller isn't
+// allowed to change the mode or the device doesn't support it, respectively.
+func (d nvmlDevice) SetComputeMode(mode ComputeMode) error {
+	if C.nvmlHandle == nil {
+		return errLibraryNotLoaded
+	}
+	return errorString(C.nvmlDeviceSetComputeMode(d.dev, C.nvmlComputeMode_t(mode)))
+}
+
+// PowerLimit returns the power management limit of the device in milliwatts.
+// If the device's total power draw reaches this limit the power management
+// algorithm kicks in.
+func (d nvmlDevice) PowerLimit() (uint, error) {
 	if C.nvmlHandle == nil {
 		return 0, errLibraryNotLoaded
 	}
-	lastTs := C.ulonglong(time.Now().Add(-1*since).UnixNano() / 1000)
 	var n C.uint
-	r := C.nvmlDeviceGetAverageUsage(d.dev, C.NVML_TOTAL_POWER_SAMPLES, lastTs, &n)
+	r := C.nvmlDeviceGetPowerManagementLimit(d.dev, &n)
 	return uint(n), errorString(r)
 }
 
-// AverageGPUUtilization returns the utilization.gpu metric (percent of time
-// one of more kernels were executing on the GPU) averaged over the samples
-// collected in the last `since` duration.
-func (d Device) AverageGPUUtilization(since time.Duration) (uint, error) {
+// DefaultPowerLimit returns the power management limit the device boots with,
+// in milliwatts.
+func (d nvmlDevice) DefaultPowerLimit() (uint, error) {
 	if C.nvmlHandle == nil {
 		return 0, errLibraryNotLoaded
 	}
-	lastTs := C.ulonglong(time.Now().Add(-1*since).UnixNano() / 1000)
 	var n C.uint
-	r := C.nvmlDeviceGetAverageUsage(d.dev, C.NVML_GPU_UTILIZATION_SAMPLES, lastTs, &n)
+	r := C.nvmlDeviceGetPowerManagementDefaultLimit(d.dev, &n)
 	return uint(n), errorString(r)
 }
 
-// Temperature returns the temperature for this GPU in Celsius.
-func (d Device) Temperature() (uint, error) {
+// PowerLimitConstraints returns the minimum and maximum power management
+// limit of the device in milliwatts.
+func (d nvmlDevice) PowerLimitConstraints() (uint, uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, 0, errLibraryNotLoaded
+	}
+	var min, max C.uint
+	r := C.nvmlDeviceGetPowerManagementLimitConstraints(d.dev, &min, &max)
+	return uint(min), uint(max), errorString(r)
+}
+
+// SetPowerLimit sets the power management limit of the device in milliwatts.
+// Device.SetPowerLimit() has checked the limit against the constraints.
+func (d nvmlDevice) SetPowerLimit(milliwatts uint) error {
+	if C.nvmlHandle == nil {
+		return errLibraryNotLoaded
+	}
+	return errorString(C.nvmlDeviceSetPowerManagementLimit(d.dev, C.uint(milliwatts)))
+}
+
+// ApplicationsClock returns the clock in MHz that applications will be
+// running at for the given clock domain. The device may run at a lower clock
+// when throttled.
+func (d nvmlDevice) ApplicationsClock(clockType ClockType) (uint, error) {
 	if C.nvmlHandle == nil {
 		return 0, errLibraryNotLoaded
 	}
 	var n C.uint
-	r := C.nvmlDeviceGetTemperature(d.dev, C.NVML_TEMPERATURE_GPU, &n)
+	r := C.nvmlDeviceGetApplicationsClock(d.dev, C.nvmlClockType_t(clockType), &n)
 	return uint(n), errorString(r)
 }
 
-// FanSpeed returns the temperature for this GPU in the percentage of its full
-// speed, with 100 being the maximum.
-func (d Device) FanSpeed() (uint, error) {
+// DefaultApplicationsClock returns the default applications clock in MHz for
+// the given clock domain, i.e. the one the device boots with.
+func (d nvmlDevice) DefaultApplicationsClock(clockType ClockType) (uint, error) {
 	if C.nvmlHandle == nil {
 		return 0, errLibraryNotLoaded
 	}
 	var n C.uint
-	r := C.nvmlDeviceGetFanSpeed(d.dev, &n)
+	r := C.nvmlDeviceGetDefaultApplicationsClock(d.dev, C.nvmlClockType_t(clockType), &n)
 	return uint(n), errorString(r)
 }
 
-// EncoderUtilization returns the percent of time over the last sample period during which the GPU video encoder was being used.
-// The sampling period is variable and is returned in the second return argument in microseconds.
-func (d Device) EncoderUtilization() (uint, uint, error) {
+// SupportedMemoryClocks returns the memory clocks in MHz that can be passed
+// to SetApplicationsClocks().
+func (d nvmlDevice) SupportedMemoryClocks() ([]uint, error) {
+	return supportedClocks(func(n *C.uint, clocks *C.uint) C.nvmlReturn_t {
+		return C.nvmlDeviceGetSupportedMemoryClocks(d.dev, n, clocks)
+	})
+}
+
+// SupportedGraphicsClocks returns the graphics clocks in MHz that can be
+// passed to SetApplicationsClocks() along with the given memory clock.
+func (d nvmlDevice) SupportedGraphicsClocks(memoryClockMHz uint) ([]uint, error) {
+	return supportedClocks(func(n *C.uint, clocks *C.uint) C.nvmlReturn_t {
+		return C.nvmlDeviceGetSupportedGraphicsClocks(d.dev, C.uint(memoryClockMHz), n, clocks)
+	})
+}
+
+// supportedClocks returns the clocks listed by get, which is either
+// nvmlDeviceGetSupportedMemoryClocks or nvmlDeviceGetSupportedGraphicsClocks.
+func supportedClocks(get func(*C.uint, *C.uint) C.nvmlReturn_t) ([]uint, error) {
 	if C.nvmlHandle == nil {
-		return 0, 0, errLibraryNotLoaded
+		return nil, errLibraryNotLoaded
 	}
+	// Invoking the method with count set to 0 returns
+	// NVML_ERROR_INSUFFICIENT_SIZE along with the count. The count may grow
+	// between the calls, so the query is retried once with the new count.
 	var n C.uint
-	var sp C.uint
-	r := C.nvmlDeviceGetEncoderUtilization(d.dev, &n, &sp)
-	return uint(n), uint(sp), errorString(r)
+	r := get(&n, nil)
+	if r != C.NVML_SUCCESS && r != C.NVML_ERROR_INSUFFICIENT_SIZE {
+		return nil, errorString(r)
+	}
+	for retry := 0; ; retry++ {
+		if n == 0 {
+			return []uint{}, nil
+		}
+		clocks := make([]C.uint, n)
+		r = get(&n, &clocks[0])
+		if r == C.NVML_ERROR_INSUFFICIENT_SIZE && retry == 0 {
+			continue
+		}
+		if r != C.NVML_SUCCESS {
+			return nil, errorString(r)
+		}
+		result := make([]uint, n)
+		for i := range result {
+			result[i] = uint(clocks[i])
+		}
+		return result, nil
+	}
 }
 
-// DecoderUtilization returns the percent of time over the last sample period during which the GPU video decoder was being used.
-// The sampling period is variable and is returned in the second return argument in microseconds.
-func (d Device) DecoderUtilization() (uint, uint, error) {
+// SetApplicationsClocks sets the clocks in MHz that applications will lock to.
+// Device.SetApplicationsClocks() has checked that the device supports them.
+func (d nvmlDevice) SetApplicationsClocks(memoryClockMHz, graphicsClockMHz uint) error {
 	if C.nvmlHandle == nil {
-		return 0, 0, errLibraryNotLoaded
+		return errLibraryNotLoaded
 	}
-	var n C.uint
-	var sp C.uint
-	r := C.nvmlDeviceGetDecoderUtilization(d.dev, &n, &sp)
-	return uint(n), uint(sp), errorString(r)
+	return errorString(C.nvmlDeviceSetApplicationsClocks(d.dev, C.uint(memoryClockMHz), C.uint(graphicsClockMHz)))
+}
+
+// ResetApplicationsClocks resets the applications clocks to their default
+// values. On Pascal and newer this also restores auto boosting of clocks.
+// Requires root.
+func (d nvmlDevice) ResetApplicationsClocks() error {
+	if C.nvmlHandle == nil {
+		return errLibraryNotLoaded
+	}
+	return errorString(C.nvmlDeviceResetApplicationsClocks(d.dev))
+}
+
+// AutoBoostedClocksEnabled returns whether auto boosted clocks are currently
+// enabled and whether they are enabled by default, i.e. when no compute
+// processes are running.
+func (d nvmlDevice) AutoBoostedClocksEnabled() (bool, bool, error) {
+	if C.nvmlHandle == nil {
+		return false, false, errLibraryNotLoaded
+	}
+	var enabled, defaultEnabled C.nvmlEnableState_t
+	r := C.nvmlDeviceGetAutoBoostedClocksEnabled(d.dev, &enabled, &defaultEnabled)
+	return enabled == C.NVML_FEATURE_ENABLED, defaultEnabled == C.NVML_FEATURE_ENABLED, errorString(r)
+}
+
+// SetAutoBoostedClocksEnabled enables or disables auto boosted clocks for the
+// calling process. The setting reverts to the default once no compute
+// processes are running.
+// On Pascal and newer auto boosting is controlled through
+// SetApplicationsClocks() and ResetApplicationsClocks() instead.
+func (d nvmlDevice) SetAutoBoostedClocksEnabled(enabled bool) error {
+	if C.nvmlHandle == nil {
+		return errLibraryNotLoaded
+	}
+	return errorString(C.nvmlDeviceSetAutoBoostedClocksEnabled(d.dev, enableState(enabled)))
+}
+
+// SetDefaultAutoBoostedClocksEnabled sets whether auto boosted clocks are
+// enabled by default, i.e. when no compute processes are running.
+// Requires root.
+func (d nvmlDevice) SetDefaultAutoBoostedClocksEnabled(enabled bool) error {
+	if C.nvmlHandle == nil {
+		return errLibraryNotLoaded
+	}
+	return errorString(C.nvmlDeviceSetDefaultAutoBoostedClocksEnabled(d.dev, enableState(enabled), 0))
+}
+
+// enableState converts a bool into a nvmlEnableState_t.
+func enableState(enabled bool) C.nvmlEnableState_t {
+	if enabled {
+		return C.NVML_FEATURE_ENABLED
+	}
+	return C.NVML_FEATURE_DISABLED
+}
+
+// EccMode returns whether ECC is currently enabled on the device and whether
+// it will be enabled after the next reboot.
+func (d nvmlDevice) EccMode() (bool, bool, error) {
+	if C.nvmlHandle == nil {
+		return false, false, errLibraryNotLoaded
+	}
+	var current, pending C.nvmlEnableState_t
+	r := C.nvmlDeviceGetEccMode(d.dev, &current, &pending)
+	return current == C.NVML_FEATURE_ENABLED, pending == C.NVML_FEATURE_ENABLED, errorString(r)
+}
+
+// SetEccMode enables or disables ECC on the device. The change only takes
+// effect after the next reboot; the returned bool reports whether a reboot is
+// needed, i.e. whether the new mode differs from the current one.
+// Requires root.
+func (d nvmlDevice) SetEccMode(enabled bool) (bool, error) {
+	if C.nvmlHandle == nil {
+		return false, errLibraryNotLoaded
+	}
+	r := C.nvmlDeviceSetEccMode(d.dev, enableState(enabled))
+	if r != C.NVML_SUCCESS {
+		return false, errorString(r)
+	}
+	current, pending, err := d.EccMode()
+	if err != nil {
+		return false, err
+	}
+	return current != pending, nil
+}
+
+// ClearEccErrorCounts resets the given ECC error counters, both detailed and
+// total, to 0. ECC must be enabled. Requires root.
+func (d nvmlDevice) ClearEccErrorCounts(counterType EccCounterType) error {
+	if C.nvmlHandle == nil {
+		return errLibraryNotLoaded
+	}
+	return errorString(C.nvmlDeviceClearEccErrorCounts(d.dev, C.nvmlEccCounterType_t(counterType)))
+}
+
+// AccountingMode returns whether per process accounting is enabled.
+func (d nvmlDevice) AccountingMode() (bool, error) {
+	if C.nvmlHandle == nil {
+		return false, errLibraryNotLoaded
+	}
+	var mode C.nvmlEnableState_t
+	r := C.nvmlDeviceGetAccountingMode(d.dev, &mode)
+	return mode == C.NVML_FEATURE_ENABLED, errorString(r)
+}
+
+// SetAccountingMode enables or disables per process accounting. Disabling it
+// clears the accounting information of all processes. The setting doesn't
+// survive a driver unload, so persistence mode should be enabled as well.
+// Requires root.
+func (d nvmlDevice) SetAccountingMode(enabled bool) error {
+	if C.nvmlHandle == nil {
+		return errLibraryNotLoaded
+	}
+	return errorString(C.nvmlDeviceSetAccountingMode(d.dev, enableState(enabled)))
+}
+
+// ClearAccountingPids clears the accounting information of all processes
+// that have already terminated. Requires root.
+func (d nvmlDevice) ClearAccountingPids() error {
+	if C.nvmlHandle == nil {
+		return errLibraryNotLoaded
+	}
+	return errorString(C.nvmlDeviceClearAccountingPids(d.dev))
+}
+
+// APIRestriction returns whether the given API is restricted to root.
+func (d nvmlDevice) APIRestriction(api RestrictedAPI) (bool, error) {
+	if C.nvmlHandle == nil {
+		return false, errLibraryNotLoaded
+	}
+	var restricted C.nvmlEnableState_t
+	r := C.nvmlDeviceGetAPIRestriction(d.dev, C.nvmlRestrictedAPI_t(api), &restricted)
+	return restricted == C.NVML_FEATURE_ENABLED, errorString(r)
+}
+
+// SetAPIRestriction restricts the given API to root or lifts the restriction.
+// The setting lasts until the driver is unloaded. Requires root.
+func (d nvmlDevice) SetAPIRestriction(api RestrictedAPI, restricted bool) error {
+	if C.nvmlHandle == nil {
+		return errLibraryNotLoaded
+	}
+	return errorString(C.nvmlDeviceSetAPIRestriction(d.dev, C.nvmlRestrictedAPI_t(api), enableState(restricted)))
+}
+
+// CurrentClocksThrottleReasons returns the reasons why the clocks of the
+// device are currently being held below their maximum. More than one reason
+// may be active at the same time.
+func (d nvmlDevice) CurrentClocksThrottleReasons() (ClocksThrottleReasons, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var reasons C.ulonglong
+	r := C.nvmlDeviceGetCurrentClocksThrottleReasons(d.dev, &reasons)
+	return ClocksThrottleReasons(reasons), errorString(r)
 }
@@ -40,6 +40,20 @@ func main() {
 	}
 	fmt.Printf("SystemDriverVersion(): %v\n", driverVersion)
 
+	nvmlVersion, err := gonvml.SystemNVMLVersion()
+	if err != nil {
+		fmt.Printf("SystemNVMLVersion() error: %v\n", err)
+	} else {
+		fmt.Printf("SystemNVMLVersion(): %v\n", nvmlVersion)
+	}
+
+	cudaDriverVersion, err := gonvml.SystemCudaDriverVersion()
+	if err != nil {
+		fmt.Printf("SystemCudaDriverVersion() error: %v\n", err)
+	} else {
+		fmt.Printf("SystemCudaDriverVersion(): %v\n", cudaDriverVersion)
+	}
+
 	numDevices, err := gonvml.DeviceCount()
 	if err != nil {
 		fmt.Printf("DeviceCount() error: %v\n", err)
@@ -75,6 +89,27 @@ func main() {
 			fmt.Printf("\t\tname: %v\n", name)
 		}
 
+		serial, err := dev.Serial()
+		if err != nil {
+			fmt.Printf("\t\tdev.Serial() error: %v\n", err)
+		} else {
+			fmt.Printf("\t\tserial: %v\n", serial)
+		}
+
+		vbiosVersion, err := dev.VbiosVersion()
+		if err != nil {
+			fmt.Printf("\t\tdev.VbiosVersion() error: %v\n", err)
+		} else {
+			fmt.Printf("\t\tvbios_version: %v\n", vbiosVersion)
+		}
+
+		brand, err := dev.Brand()
+		if err != nil {
+			fmt.Printf("\t\tdev.Brand() error: %v\n", err)
+		} else {
+			fmt.Printf("\t\tbrand: %v\n", brand)
+		}
+
 		totalMemory, usedMemory, err := dev.MemoryInfo()
 		if err != nil {
 			fmt.Printf("\t\tdev.MemoryInfo() error: %v\n", err)
@@ -117,6 +152,13 @@ func main() {
 			fmt.Printf("\t\ttemperature.gpu: %v C\n", temperature)
 		}
 
+		slowdownHeadroom, shutdownHeadroom, err := dev.ThermalHeadroom()
+		if err != nil {
+			fmt.Printf("\t\tdev.ThermalHeadroom() error: %v\n", err)
+		} else {
+			fmt.Printf("\t\theadroom.slowdown: %v C, headroom.shutdown: %v C\n", slowdownHeadroom, shutdownHeadroom)
+		}
+
 		fanSpeed, err := dev.FanSpeed()
 		if err != nil {
 			fmt.Printf("\t\tdev.FanSpeed() error: %v\n", err)
@@ -124,6 +166,20 @@ func main() {
 			fmt.Printf("\t\tfan.speed: %v%%\n", fanSpeed)
 		}
 
+		pcieLinkGen, err := dev.CurrPcieLinkGeneration()
+		if err != nil {
+			fmt.Printf("\t\tdev.CurrPcieLinkGeneration() error: %v\n", err)
+		} else {
+			fmt.Printf("\t\tpcie.link.gen.current: %v\n", pcieLinkGen)
+		}
+
+		pcieLinkWidth, err := dev.CurrPcieLinkWidth()
+		if err != nil {
+			fmt.Printf("\t\tdev.CurrPcieLinkWidth() error: %v\n", err)
+		} else {
+			fmt.Printf("\t\tpcie.link.width.current: %v\n", pcieLinkWidth)
+		}
+
 		encoderUtilization, _, err := dev.EncoderUtilization()
 		if err != nil {
 			fmt.Printf("\t\tdev.EncoderUtilization() error: %v\n", err)
@@ -0,0 +1,277 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package drift detects GPUs whose configuration differs from a baseline.
+//
+// A Snapshot records the configuration of a device. The baseline is a
+// Snapshot saved as JSON, typically captured on a known good node:
+//
+//	baseline, err := drift.LoadBaseline("baseline.json")
+//	...
+//	snapshots, err := drift.CaptureAll()
+//	...
+//	for i, s := range snapshots {
+//		for _, d := range drift.Compare(baseline, s) {
+//			fmt.Printf("GPU %d: %v\n", i, d)
+//		}
+//	}
+//
+// Fields left empty in the baseline are not compared, so a baseline can be
+// trimmed down to the settings that matter. gonvml.Initialize() must have
+// been called before capturing snapshots.
+package drift
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/mindprince/gonvml"
+)
+
+// Snapshot is the configuration of a device. Settings the device doesn't
+// support are left empty.
+type Snapshot struct {
+	DriverVersion       string `json:"driverVersion,omitempty"`
+	Name                string `json:"name,omitempty"`
+	VbiosVersion        string `json:"vbiosVersion,omitempty"`
+	InforomImageVersion string `json:"inforomImageVersion,omitempty"`
+	// EccMode is the current mode, EccModePending the one in effect after
+	// the next reboot.
+	EccMode         string `json:"eccMode,omitempty"`
+	EccModePending  string `json:"eccModePending,omitempty"`
+	PersistenceMode string `json:"persistenceMode,omitempty"`
+	ComputeMode     string `json:"computeMode,omitempty"`
+	// PowerLimit is in milliwatts.
+	PowerLimit uint `json:"powerLimit,omitempty"`
+	// MemoryClock and GraphicsClock are the applications clocks in MHz.
+	MemoryClock   uint `json:"memoryClock,omitempty"`
+	GraphicsClock uint `json:"graphicsClock,omitempty"`
+	// PcieLinkGeneration and PcieLinkWidth are the current link, which is
+	// compared against MaxPcieLinkGeneration and MaxPcieLinkWidth rather
+	// than the baseline. The generation may drop while the GPU is idle, so
+	// snapshots are best captured under load.
+	PcieLinkGeneration    uint `json:"pcieLinkGeneration,omitempty"`
+	PcieLinkWidth         uint `json:"pcieLinkWidth,omitempty"`
+	MaxPcieLinkGeneration uint `json:"maxPcieLinkGeneration,omitempty"`
+	MaxPcieLinkWidth      uint `json:"maxPcieLinkWidth,omitempty"`
+}
+
+// Capture returns the snapshot of the device with the given index.
+func Capture(idx uint) (Snapshot, error) {
+	driverVersion, err := gonvml.SystemDriverVersion()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	dev, err := gonvml.DeviceHandleByIndex(idx)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return capture(dev, driverVersion)
+}
+
+// CaptureAll returns the snapshots of all the devices of the node, in index
+// order.
+func CaptureAll() ([]Snapshot, error) {
+	driverVersion, err := gonvml.SystemDriverVersion()
+	if err != nil {
+		return nil, err
+	}
+	count, err := gonvml.DeviceCount()
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make([]Snapshot, count)
+	for i := range snapshots {
+		dev, err := gonvml.DeviceHandleByIndex(uint(i))
+		if err != nil {
+			return nil, fmt.Errorf("GPU %d: %v", i, err)
+		}
+		if snapshots[i], err = capture(dev, driverVersion); err != nil {
+			return nil, fmt.Errorf("GPU %d: %v", i, err)
+		}
+	}
+	return snapshots, nil
+}
+
+func capture(dev gonvml.Device, driverVersion string) (Snapshot, error) {
+	// The first error other than gonvml.ErrNotSupported and
+	// gonvml.ErrFunctionNotFound is returned; unsupported settings are left
+	// empty.
+	var err error
+	supported := func(what string, e error) bool {
+		unsupported := e == gonvml.ErrNotSupported || e == gonvml.ErrFunctionNotFound
+		if e != nil && !unsupported && err == nil {
+			err = fmt.Errorf("reading %s: %v", what, e)
+		}
+		return e == nil
+	}
+	str := func(what string, get func() (string, error)) string {
+		v, e := get()
+		if !supported(what, e) {
+			return ""
+		}
+		return v
+	}
+	num := func(what string, get func() (uint, error)) uint {
+		v, e := get()
+		if !supported(what, e) {
+			return 0
+		}
+		return v
+	}
+	clock := func(clockType gonvml.ClockType) func() (uint, error) {
+		return func() (uint, error) { return dev.ApplicationsClock(clockType) }
+	}
+
+	s := Snapshot{
+		DriverVersion:         driverVersion,
+		Name:                  str("name", dev.Name),
+		VbiosVersion:          str("VBIOS version", dev.VbiosVersion),
+		InforomImageVersion:   str("InfoROM image version", dev.InforomImageVersion),
+		PowerLimit:            num("power limit", dev.PowerLimit),
+		MemoryClock:           num("memory applications clock", clock(gonvml.ClockMemory)),
+		GraphicsClock:         num("graphics applications clock", clock(gonvml.ClockGraphics)),
+		PcieLinkGeneration:    num("PCIe link generation", dev.CurrPcieLinkGeneration),
+		PcieLinkWidth:         num("PCIe link width", dev.CurrPcieLinkWidth),
+		MaxPcieLinkGeneration: num("max PCIe link generation", dev.MaxPcieLinkGeneration),
+		MaxPcieLinkWidth:      num("max PCIe link width", dev.MaxPcieLinkWidth),
+	}
+	if current, pending, e := dev.EccMode(); supported("ECC mode", e) {
+		s.EccMode = enableState(current)
+		s.EccModePending = enableState(pending)
+	}
+	if enabled, e := dev.PersistenceMode(); supported("persistence mode", e) {
+		s.PersistenceMode = enableState(enabled)
+	}
+	if mode, e := dev.ComputeMode(); supported("compute mode", e) {
+		s.ComputeMode = mode.String()
+	}
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return s, nil
+}
+
+// LoadBaseline reads a Snapshot saved as JSON by SaveBaseline().
+func LoadBaseline(path string) (Snapshot, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return Snapshot{}, fmt.Errorf("parsing baseline %s: %v", path, err)
+	}
+	return s, nil
+}
+
+// SaveBaseline writes s as JSON to path.
+func SaveBaseline(path string, s Snapshot) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Difference is a field whose value differs from the expected one.
+type Difference struct {
+	// Field is the JSON name of the field, e.g. "vbiosVersion".
+	Field string
+	// Expected is the value in the baseline, or the maximum of the link for
+	// "pcieLinkGeneration" and "pcieLinkWidth".
+	Expected string
+	// Current is empty if the device doesn't support the setting.
+	Current string
+}
+
+func (d Difference) String() string {
+	current := d.Current
+	if current == "" {
+		current = "N/A"
+	}
+	return fmt.Sprintf("%s: %s, expected %s", d.Field, current, d.Expected)
+}
+
+// Compare returns the fields of current that differ from baseline. Fields
+// that are empty in baseline are skipped. The PCIe link of current is
+// compared against its own maximums, so a link that trained at a lower
+// generation or width than the device supports is reported as well.
+func Compare(baseline, current Snapshot) []Difference {
+	var diffs []Difference
+	b, c := baseline.fields(), current.fields()
+	for i := range b {
+		if b[i].value == "" || b[i].value == c[i].value {
+			continue
+		}
+		diffs = append(diffs, Difference{Field: b[i].name, Expected: b[i].value, Current: c[i].value})
+	}
+	if current.PcieLinkGeneration != 0 && current.PcieLinkGeneration < current.MaxPcieLinkGeneration {
+		diffs = append(diffs, Difference{
+			Field:    "pcieLinkGeneration",
+			Expected: uintField(current.MaxPcieLinkGeneration),
+			Current:  uintField(current.PcieLinkGeneration),
+		})
+	}
+	if current.PcieLinkWidth != 0 && current.PcieLinkWidth < current.MaxPcieLinkWidth {
+		diffs = append(diffs, Difference{
+			Field:    "pcieLinkWidth",
+			Expected: uintField(current.MaxPcieLinkWidth),
+			Current:  uintField(current.PcieLinkWidth),
+		})
+	}
+	return diffs
+}
+
+type field struct {
+	name  string
+	value string
+}
+
+// fields returns the fields of s that are compared against the baseline in a
+// fixed order, with empty values for the ones that are not set.
+func (s Snapshot) fields() []field {
+	return []field{
+		{"driverVersion", s.DriverVersion},
+		{"name", s.Name},
+		{"vbiosVersion", s.VbiosVersion},
+		{"inforomImageVersion", s.InforomImageVersion},
+		{"eccMode", s.EccMode},
+		{"eccModePending", s.EccModePending},
+		{"persistenceMode", s.PersistenceMode},
+		{"computeMode", s.ComputeMode},
+		{"powerLimit", uintField(s.PowerLimit)},
+		{"memoryClock", uintField(s.MemoryClock)},
+		{"graphicsClock", uintField(s.GraphicsClock)},
+		{"maxPcieLinkGeneration", uintField(s.MaxPcieLinkGeneration)},
+		{"maxPcieLinkWidth", uintField(s.MaxPcieLinkWidth)},
+	}
+}
+
+func uintField(v uint) string {
+	if v == 0 {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func enableState(enabled bool) string {
+	if enabled {
+		return gonvml.Enabled.String()
+	}
+	return gonvml.Disabled.String()
+}
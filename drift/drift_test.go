@@ -0,0 +1,171 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package drift
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mindprince/gonvml"
+	"github.com/mindprince/gonvml/gonvmltest"
+)
+
+func TestCaptureAll(t *testing.T) {
+	fake := &gonvmltest.Backend{
+		DriverVersion: "390.46",
+		Devices: []*gonvmltest.Device{{
+			Name:                   "Tesla V100-SXM2-16GB",
+			InforomImageVersion:    "G503.0201.00.03",
+			EccMode:                true,
+			EccModePending:         true,
+			PowerLimit:             300000,
+			MemoryClock:            877,
+			GraphicsClock:          1530,
+			CurrPcieLinkGeneration: 3,
+			CurrPcieLinkWidth:      8,
+			MaxPcieLinkGeneration:  3,
+			MaxPcieLinkWidth:       16,
+			Errors: map[string]error{
+				"VbiosVersion":    gonvml.ErrFunctionNotFound,
+				"PersistenceMode": gonvml.ErrNotSupported,
+			},
+		}},
+	}
+	prev := gonvml.SetBackend(fake)
+	defer gonvml.SetBackend(prev)
+
+	got, err := CaptureAll()
+	if err != nil {
+		t.Fatalf("CaptureAll() error: %v", err)
+	}
+	want := []Snapshot{{
+		DriverVersion:         "390.46",
+		Name:                  "Tesla V100-SXM2-16GB",
+		InforomImageVersion:   "G503.0201.00.03",
+		EccMode:               "Enabled",
+		EccModePending:        "Enabled",
+		ComputeMode:           "Default",
+		PowerLimit:            300000,
+		MemoryClock:           877,
+		GraphicsClock:         1530,
+		PcieLinkGeneration:    3,
+		PcieLinkWidth:         8,
+		MaxPcieLinkGeneration: 3,
+		MaxPcieLinkWidth:      16,
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CaptureAll() = %+v, want %+v", got, want)
+	}
+
+	fake.Devices[0].Errors["PowerLimit"] = gonvml.ErrNoPermission
+	if _, err := Capture(0); err == nil {
+		t.Error("Capture() with an unreadable power limit succeeded, want an error")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	baseline := Snapshot{
+		DriverVersion:   "390.46",
+		VbiosVersion:    "88.00.4F.00.09",
+		PersistenceMode: "Enabled",
+		PowerLimit:      300000,
+	}
+	current := Snapshot{
+		DriverVersion: "390.46",
+		Name:          "Tesla V100-SXM2-16GB",
+		VbiosVersion:  "88.00.43.00.02",
+		PowerLimit:    250000,
+	}
+	want := []Difference{
+		{Field: "vbiosVersion", Expected: "88.00.4F.00.09", Current: "88.00.43.00.02"},
+		{Field: "persistenceMode", Expected: "Enabled"},
+		{Field: "powerLimit", Expected: "300000", Current: "250000"},
+	}
+	if got := Compare(baseline, current); !reflect.DeepEqual(got, want) {
+		t.Errorf("Compare() = %v, want %v", got, want)
+	}
+	if got := want[1].String(); got != "persistenceMode: N/A, expected Enabled" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := Compare(current, current); len(got) != 0 {
+		t.Errorf("Compare() of a snapshot with itself = %v, want no differences", got)
+	}
+}
+
+func TestComparePcieLink(t *testing.T) {
+	tests := []struct {
+		current Snapshot
+		want    []Difference
+	}{
+		{current: Snapshot{PcieLinkGeneration: 3, PcieLinkWidth: 16, MaxPcieLinkGeneration: 3, MaxPcieLinkWidth: 16}},
+		{
+			// An x16 device that trained at x8 in Gen2.
+			current: Snapshot{PcieLinkGeneration: 2, PcieLinkWidth: 8, MaxPcieLinkGeneration: 3, MaxPcieLinkWidth: 16},
+			want: []Difference{
+				{Field: "pcieLinkGeneration", Expected: "3", Current: "2"},
+				{Field: "pcieLinkWidth", Expected: "16", Current: "8"},
+			},
+		},
+		// The current link can't be read.
+		{current: Snapshot{MaxPcieLinkGeneration: 3, MaxPcieLinkWidth: 16}},
+	}
+	for _, tt := range tests {
+		// The baseline doesn't matter for the link.
+		baseline := Snapshot{PcieLinkWidth: 16, MaxPcieLinkWidth: 16}
+		if got := Compare(baseline, tt.current); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Compare(%+v) = %v, want %v", tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestBaselineRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "drift")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "baseline.json")
+	s := Snapshot{
+		DriverVersion:    "390.46",
+		Name:             "Tesla V100-SXM2-16GB",
+		EccMode:          "Enabled",
+		ComputeMode:      "Exclusive_Process",
+		PowerLimit:       300000,
+		MaxPcieLinkWidth: 16,
+	}
+	if err := SaveBaseline(path, s); err != nil {
+		t.Fatalf("SaveBaseline() error: %v", err)
+	}
+	got, err := LoadBaseline(path)
+	if err != nil {
+		t.Fatalf("LoadBaseline() error: %v", err)
+	}
+	if got != s {
+		t.Errorf("LoadBaseline() = %+v, want %+v", got, s)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBaseline(path); err == nil {
+		t.Error("LoadBaseline() of invalid JSON succeeded, want an error")
+	}
+}
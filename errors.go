@@ -0,0 +1,68 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotSupported is returned when the device doesn't support the
+	// requested operation.
+	ErrNotSupported = errors.New("nvml: Not Supported")
+
+	// ErrNoPermission is returned when the calling process doesn't have
+	// permission to perform the requested operation, e.g. a setter that
+	// requires root.
+	ErrNoPermission = errors.New("nvml: Insufficient Permissions")
+
+	// ErrFunctionNotFound is returned when the loaded NVML library is too old
+	// to provide the requested function.
+	ErrFunctionNotFound = errors.New("nvml: Function Not Found")
+
+	// ErrNoSamples is returned by AveragePowerUsage() and
+	// AverageGPUUtilization() when the driver collected no samples in the
+	// requested duration.
+	ErrNoSamples = errors.New("nvml: no samples in the requested duration")
+)
+
+// PowerLimitRangeError is returned by Device.SetPowerLimit() when the
+// requested limit is outside of the device's power limit constraints.
+type PowerLimitRangeError struct {
+	// Limit is the requested limit in milliwatts.
+	Limit uint
+	// Min and Max are the constraints of the device in milliwatts.
+	Min uint
+	Max uint
+}
+
+func (e *PowerLimitRangeError) Error() string {
+	return fmt.Sprintf("nvml: power limit %d mW is out of range [%d mW, %d mW]", e.Limit, e.Min, e.Max)
+}
+
+// UnsupportedClocksError is returned by Device.SetApplicationsClocks() when
+// the requested pair of clocks is not supported by the device.
+type UnsupportedClocksError struct {
+	// MemoryClock and GraphicsClock are the requested clocks in MHz.
+	MemoryClock   uint
+	GraphicsClock uint
+}
+
+func (e *UnsupportedClocksError) Error() string {
+	return fmt.Sprintf("nvml: applications clocks %d MHz memory, %d MHz graphics are not supported", e.MemoryClock, e.GraphicsClock)
+}
@@ -0,0 +1,440 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvmltest
+
+import (
+	"time"
+
+	"github.com/mindprince/gonvml"
+)
+
+func (h device) MinorNumber() (uint, error) {
+	if err := h.d.Errors["MinorNumber"]; err != nil {
+		return 0, err
+	}
+	return h.d.MinorNumber, nil
+}
+
+func (h device) UUID() (string, error) {
+	if err := h.d.Errors["UUID"]; err != nil {
+		return "", err
+	}
+	return h.d.UUID, nil
+}
+
+func (h device) Name() (string, error) {
+	if err := h.d.Errors["Name"]; err != nil {
+		return "", err
+	}
+	return h.d.Name, nil
+}
+
+func (h device) PowerUsage() (uint, error) {
+	if err := h.d.Errors["PowerUsage"]; err != nil {
+		return 0, err
+	}
+	return h.d.PowerUsage, nil
+}
+
+func (h device) Temperature() (uint, error) {
+	if err := h.d.Errors["Temperature"]; err != nil {
+		return 0, err
+	}
+	return h.d.Temperature, nil
+}
+
+func (h device) VbiosVersion() (string, error) {
+	if err := h.d.Errors["VbiosVersion"]; err != nil {
+		return "", err
+	}
+	return h.d.VbiosVersion, nil
+}
+
+func (h device) InforomImageVersion() (string, error) {
+	if err := h.d.Errors["InforomImageVersion"]; err != nil {
+		return "", err
+	}
+	return h.d.InforomImageVersion, nil
+}
+
+func (h device) Serial() (string, error) {
+	if err := h.d.Errors["Serial"]; err != nil {
+		return "", err
+	}
+	return h.d.Serial, nil
+}
+
+func (h device) Index() (uint, error) {
+	if err := h.d.Errors["Index"]; err != nil {
+		return 0, err
+	}
+	return h.d.Index, nil
+}
+
+func (h device) ComputeMode() (gonvml.ComputeMode, error) {
+	if err := h.d.Errors["ComputeMode"]; err != nil {
+		return 0, err
+	}
+	return h.d.ComputeMode, nil
+}
+
+func (h device) PersistenceMode() (bool, error) {
+	if err := h.d.Errors["PersistenceMode"]; err != nil {
+		return false, err
+	}
+	return h.d.PersistenceMode, nil
+}
+
+func (h device) PciInfo() (gonvml.PciInfo, error) {
+	if err := h.d.Errors["PciInfo"]; err != nil {
+		return gonvml.PciInfo{}, err
+	}
+	return h.d.PciInfo, nil
+}
+
+func (h device) CurrPcieLinkGeneration() (uint, error) {
+	if err := h.d.Errors["CurrPcieLinkGeneration"]; err != nil {
+		return 0, err
+	}
+	return h.d.CurrPcieLinkGeneration, nil
+}
+
+func (h device) MaxPcieLinkGeneration() (uint, error) {
+	if err := h.d.Errors["MaxPcieLinkGeneration"]; err != nil {
+		return 0, err
+	}
+	return h.d.MaxPcieLinkGeneration, nil
+}
+
+func (h device) CurrPcieLinkWidth() (uint, error) {
+	if err := h.d.Errors["CurrPcieLinkWidth"]; err != nil {
+		return 0, err
+	}
+	return h.d.CurrPcieLinkWidth, nil
+}
+
+func (h device) MaxPcieLinkWidth() (uint, error) {
+	if err := h.d.Errors["MaxPcieLinkWidth"]; err != nil {
+		return 0, err
+	}
+	return h.d.MaxPcieLinkWidth, nil
+}
+
+func (h device) ComputeRunningProcesses() ([]gonvml.ProcessInfo, error) {
+	if err := h.d.Errors["ComputeRunningProcesses"]; err != nil {
+		return nil, err
+	}
+	return h.d.ComputeRunningProcesses, nil
+}
+
+func (h device) GraphicsRunningProcesses() ([]gonvml.ProcessInfo, error) {
+	if err := h.d.Errors["GraphicsRunningProcesses"]; err != nil {
+		return nil, err
+	}
+	return h.d.GraphicsRunningProcesses, nil
+}
+
+func (h device) SetPersistenceMode(enabled bool) error {
+	if err := h.d.Errors["SetPersistenceMode"]; err != nil {
+		return err
+	}
+	h.d.PersistenceMode = enabled
+	return nil
+}
+
+func (h device) SetComputeMode(mode gonvml.ComputeMode) error {
+	if err := h.d.Errors["SetComputeMode"]; err != nil {
+		return err
+	}
+	h.d.ComputeMode = mode
+	return nil
+}
+
+func (h device) PowerLimit() (uint, error) {
+	if err := h.d.Errors["PowerLimit"]; err != nil {
+		return 0, err
+	}
+	return h.d.PowerLimit, nil
+}
+
+func (h device) DefaultPowerLimit() (uint, error) {
+	if err := h.d.Errors["DefaultPowerLimit"]; err != nil {
+		return 0, err
+	}
+	return h.d.DefaultPowerLimit, nil
+}
+
+func (h device) AccountingMode() (bool, error) {
+	if err := h.d.Errors["AccountingMode"]; err != nil {
+		return false, err
+	}
+	return h.d.AccountingMode, nil
+}
+
+func (h device) SetAccountingMode(enabled bool) error {
+	if err := h.d.Errors["SetAccountingMode"]; err != nil {
+		return err
+	}
+	h.d.AccountingMode = enabled
+	return nil
+}
+
+func (h device) CurrentClocksThrottleReasons() (gonvml.ClocksThrottleReasons, error) {
+	if err := h.d.Errors["CurrentClocksThrottleReasons"]; err != nil {
+		return 0, err
+	}
+	return h.d.ThrottleReasons, nil
+}
+
+// The methods below have no corresponding Device field.
+
+func (h device) MemoryInfo() (uint64, uint64, error) {
+	return 0, 0, h.unsupported("MemoryInfo")
+}
+
+func (h device) UtilizationRates() (uint, uint, error) {
+	return 0, 0, h.unsupported("UtilizationRates")
+}
+
+func (h device) FanSpeed() (uint, error) {
+	return 0, h.unsupported("FanSpeed")
+}
+
+func (h device) EncoderUtilization() (uint, uint, error) {
+	return 0, 0, h.unsupported("EncoderUtilization")
+}
+
+func (h device) DecoderUtilization() (uint, uint, error) {
+	return 0, 0, h.unsupported("DecoderUtilization")
+}
+
+func (h device) BoardPartNumber() (string, error) {
+	return "", h.unsupported("BoardPartNumber")
+}
+
+func (h device) Brand() (gonvml.BrandType, error) {
+	return 0, h.unsupported("Brand")
+}
+
+func (h device) BoardID() (uint, error) {
+	return 0, h.unsupported("BoardID")
+}
+
+func (h device) MultiGPUBoard() (bool, error) {
+	return false, h.unsupported("MultiGPUBoard")
+}
+
+func (h device) BridgeChipInfo() ([]gonvml.BridgeChip, error) {
+	return nil, h.unsupported("BridgeChipInfo")
+}
+
+func (h device) DisplayMode() (bool, error) {
+	return false, h.unsupported("DisplayMode")
+}
+
+func (h device) DisplayActive() (bool, error) {
+	return false, h.unsupported("DisplayActive")
+}
+
+func (h device) CudaComputeCapability() (int, int, error) {
+	return 0, 0, h.unsupported("CudaComputeCapability")
+}
+
+func (h device) EncoderCapacity(queryType gonvml.EncoderType) (uint, error) {
+	return 0, h.unsupported("EncoderCapacity")
+}
+
+func (h device) EncoderStats() (uint, uint, uint, error) {
+	return 0, 0, 0, h.unsupported("EncoderStats")
+}
+
+func (h device) EncoderSessions() ([]gonvml.EncoderSession, error) {
+	return nil, h.unsupported("EncoderSessions")
+}
+
+func (h device) SupportedVgpus() ([]gonvml.VgpuType, error) {
+	return nil, h.unsupported("SupportedVgpus")
+}
+
+func (h device) CreatableVgpus() ([]gonvml.VgpuType, error) {
+	return nil, h.unsupported("CreatableVgpus")
+}
+
+func (h device) ActiveVgpus() ([]gonvml.VgpuInstance, error) {
+	return nil, h.unsupported("ActiveVgpus")
+}
+
+func (h device) VgpuUtilization(since time.Duration) ([]gonvml.VgpuUtilizationSample, error) {
+	return nil, h.unsupported("VgpuUtilization")
+}
+
+func (h device) VgpuProcessUtilization(since time.Duration) ([]gonvml.VgpuProcessUtilizationSample, error) {
+	return nil, h.unsupported("VgpuProcessUtilization")
+}
+
+func (h device) GridLicensableFeatures() (bool, []gonvml.GridLicensableFeature, error) {
+	return false, nil, h.unsupported("GridLicensableFeatures")
+}
+
+func (h device) PcieReplayCounter() (uint, error) {
+	return 0, h.unsupported("PcieReplayCounter")
+}
+
+func (h device) PcieThroughput(counter gonvml.PcieUtilCounter) (uint, error) {
+	return 0, h.unsupported("PcieThroughput")
+}
+
+func (h device) DriverModel() (gonvml.DriverModel, gonvml.DriverModel, error) {
+	return 0, 0, h.unsupported("DriverModel")
+}
+
+func (h device) GPUOperationMode() (gonvml.GPUOperationMode, gonvml.GPUOperationMode, error) {
+	return 0, 0, h.unsupported("GPUOperationMode")
+}
+
+func (h device) VirtualizationMode() (gonvml.VirtualizationMode, error) {
+	return 0, h.unsupported("VirtualizationMode")
+}
+
+func (h device) DefaultApplicationsClock(clockType gonvml.ClockType) (uint, error) {
+	return 0, h.unsupported("DefaultApplicationsClock")
+}
+
+func (h device) ResetApplicationsClocks() error {
+	return h.unsupported("ResetApplicationsClocks")
+}
+
+func (h device) AutoBoostedClocksEnabled() (bool, bool, error) {
+	return false, false, h.unsupported("AutoBoostedClocksEnabled")
+}
+
+func (h device) SetAutoBoostedClocksEnabled(enabled bool) error {
+	return h.unsupported("SetAutoBoostedClocksEnabled")
+}
+
+func (h device) SetDefaultAutoBoostedClocksEnabled(enabled bool) error {
+	return h.unsupported("SetDefaultAutoBoostedClocksEnabled")
+}
+
+func (h device) ClearEccErrorCounts(counterType gonvml.EccCounterType) error {
+	return h.unsupported("ClearEccErrorCounts")
+}
+
+func (h device) ClearAccountingPids() error {
+	return h.unsupported("ClearAccountingPids")
+}
+
+func (h device) APIRestriction(api gonvml.RestrictedAPI) (bool, error) {
+	return false, h.unsupported("APIRestriction")
+}
+
+func (h device) SetAPIRestriction(api gonvml.RestrictedAPI, restricted bool) error {
+	return h.unsupported("SetAPIRestriction")
+}
+
+func (h device) VgpuTypeMaxInstances(t gonvml.VgpuType) (uint, error) {
+	return 0, h.unsupported("VgpuTypeMaxInstances")
+}
+
+func (h device) unsupported(method string) error {
+	if err := h.d.Errors[method]; err != nil {
+		return err
+	}
+	return gonvml.ErrNotSupported
+}
+
+// vgpuType is a vGPU handle whose methods return gonvml.ErrNotSupported.
+type vgpuType struct{}
+
+var _ gonvml.VgpuTypeBackend = vgpuType{}
+
+func (vgpuType) Class() (string, error) {
+	return "", gonvml.ErrNotSupported
+}
+
+func (vgpuType) Name() (string, error) {
+	return "", gonvml.ErrNotSupported
+}
+
+func (vgpuType) DeviceID() (uint64, uint64, error) {
+	return 0, 0, gonvml.ErrNotSupported
+}
+
+func (vgpuType) FramebufferSize() (uint64, error) {
+	return 0, gonvml.ErrNotSupported
+}
+
+func (vgpuType) NumDisplayHeads() (uint, error) {
+	return 0, gonvml.ErrNotSupported
+}
+
+func (vgpuType) Resolution(displayIndex uint) (uint, uint, error) {
+	return 0, 0, gonvml.ErrNotSupported
+}
+
+func (vgpuType) License() (string, error) {
+	return "", gonvml.ErrNotSupported
+}
+
+func (vgpuType) FrameRateLimit() (uint, error) {
+	return 0, gonvml.ErrNotSupported
+}
+
+// vgpuInstance is a vGPU handle whose methods return gonvml.ErrNotSupported.
+type vgpuInstance struct{}
+
+var _ gonvml.VgpuInstanceBackend = vgpuInstance{}
+
+func (vgpuInstance) VMID() (string, gonvml.VMIDType, error) {
+	return "", 0, gonvml.ErrNotSupported
+}
+
+func (vgpuInstance) UUID() (string, error) {
+	return "", gonvml.ErrNotSupported
+}
+
+func (vgpuInstance) VMDriverVersion() (string, error) {
+	return "", gonvml.ErrNotSupported
+}
+
+func (vgpuInstance) FramebufferUsage() (uint64, error) {
+	return 0, gonvml.ErrNotSupported
+}
+
+func (vgpuInstance) LicenseStatus() (bool, error) {
+	return false, gonvml.ErrNotSupported
+}
+
+func (vgpuInstance) Type() (gonvml.VgpuType, error) {
+	return 0, gonvml.ErrNotSupported
+}
+
+func (vgpuInstance) FrameRateLimit() (uint, error) {
+	return 0, gonvml.ErrNotSupported
+}
+
+func (vgpuInstance) EncoderCapacity() (uint, error) {
+	return 0, gonvml.ErrNotSupported
+}
+
+func (vgpuInstance) EncoderStats() (uint, uint, uint, error) {
+	return 0, 0, 0, gonvml.ErrNotSupported
+}
+
+func (vgpuInstance) EncoderSessions() ([]gonvml.EncoderSession, error) {
+	return nil, gonvml.ErrNotSupported
+}
@@ -0,0 +1,321 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gonvmltest provides an in-memory gonvml.Backend for tests that run
+// on machines without GPUs:
+//
+//	fake := &gonvmltest.Backend{
+//		DriverVersion: "390.46",
+//		Devices: []*gonvmltest.Device{
+//			{Name: "Tesla V100-SXM2-16GB", Temperature: 70},
+//		},
+//	}
+//	prev := gonvml.SetBackend(fake)
+//	defer gonvml.SetBackend(prev)
+//
+// Devices hold their state in exported fields. Setters update the fields, so
+// tests can inspect the result. Methods without a corresponding field return
+// gonvml.ErrNotSupported. The fake is not safe for concurrent use.
+package gonvmltest
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/mindprince/gonvml"
+)
+
+var (
+	errInvalidArgument = errors.New("nvml: Invalid Argument")
+	errNotFound        = errors.New("nvml: Not Found")
+)
+
+// Backend is a fake node. The zero value is a node without GPUs.
+type Backend struct {
+	DriverVersion     string
+	NVMLVersion       string
+	CudaDriverVersion int
+	// Devices are the GPUs of the node in index order.
+	Devices []*Device
+}
+
+var _ gonvml.Backend = (*Backend)(nil)
+
+// Initialize always succeeds.
+func (b *Backend) Initialize() error {
+	return nil
+}
+
+// Shutdown always succeeds.
+func (b *Backend) Shutdown() error {
+	return nil
+}
+
+// SystemDriverVersion returns b.DriverVersion.
+func (b *Backend) SystemDriverVersion() (string, error) {
+	return b.DriverVersion, nil
+}
+
+// SystemNVMLVersion returns b.NVMLVersion.
+func (b *Backend) SystemNVMLVersion() (string, error) {
+	return b.NVMLVersion, nil
+}
+
+// SystemCudaDriverVersion returns b.CudaDriverVersion.
+func (b *Backend) SystemCudaDriverVersion() (int, error) {
+	return b.CudaDriverVersion, nil
+}
+
+// SystemProcessName returns gonvml.ErrNotSupported.
+func (b *Backend) SystemProcessName(pid uint) (string, error) {
+	return "", gonvml.ErrNotSupported
+}
+
+// DeviceCount returns the number of b.Devices.
+func (b *Backend) DeviceCount() (uint, error) {
+	return uint(len(b.Devices)), nil
+}
+
+// DeviceHandleByIndex returns the handle for b.Devices[idx].
+func (b *Backend) DeviceHandleByIndex(idx uint) (gonvml.DeviceBackend, error) {
+	if idx >= uint(len(b.Devices)) {
+		return nil, errInvalidArgument
+	}
+	return device{b.Devices[idx]}, nil
+}
+
+// DeviceHandleByPciBusID returns the handle for the device whose
+// PciInfo.BusID is busID.
+func (b *Backend) DeviceHandleByPciBusID(busID string) (gonvml.DeviceBackend, error) {
+	for _, d := range b.Devices {
+		if d.PciInfo.BusID == busID {
+			return device{d}, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+// UnitCount returns 0; the fake has no S-class units.
+func (b *Backend) UnitCount() (uint, error) {
+	return 0, nil
+}
+
+// UnitHandleByIndex always fails; the fake has no S-class units.
+func (b *Backend) UnitHandleByIndex(idx uint) (gonvml.UnitBackend, error) {
+	return nil, errInvalidArgument
+}
+
+// VgpuType returns a handle whose methods return gonvml.ErrNotSupported.
+func (b *Backend) VgpuType(t gonvml.VgpuType) gonvml.VgpuTypeBackend {
+	return vgpuType{}
+}
+
+// VgpuInstance returns a handle whose methods return gonvml.ErrNotSupported.
+func (b *Backend) VgpuInstance(v gonvml.VgpuInstance) gonvml.VgpuInstanceBackend {
+	return vgpuInstance{}
+}
+
+// QueryDrainState returns gonvml.ErrNotSupported.
+func (b *Backend) QueryDrainState(pci gonvml.PciInfo) (gonvml.EnableState, error) {
+	return gonvml.Disabled, gonvml.ErrNotSupported
+}
+
+// ModifyDrainState returns gonvml.ErrNotSupported.
+func (b *Backend) ModifyDrainState(pci gonvml.PciInfo, state gonvml.EnableState) error {
+	return gonvml.ErrNotSupported
+}
+
+// RemoveGPU returns gonvml.ErrNotSupported.
+func (b *Backend) RemoveGPU(pci gonvml.PciInfo) error {
+	return gonvml.ErrNotSupported
+}
+
+// DiscoverGPUs returns gonvml.ErrNotSupported.
+func (b *Backend) DiscoverGPUs(pci gonvml.PciInfo) error {
+	return gonvml.ErrNotSupported
+}
+
+// Device is the state of a fake GPU. Each field is returned by the
+// gonvml.Device method of the same name unless noted otherwise.
+type Device struct {
+	Name                string
+	UUID                string
+	Serial              string
+	VbiosVersion        string
+	InforomImageVersion string
+	MinorNumber         uint
+	Index               uint
+	PciInfo             gonvml.PciInfo
+
+	// PowerUsage is in milliwatts.
+	PowerUsage uint
+	// Temperature, SlowdownTemperature and ShutdownTemperature are in
+	// degrees Celsius. The latter two are returned by TemperatureThreshold().
+	Temperature         uint
+	SlowdownTemperature uint
+	ShutdownTemperature uint
+	// ThrottleReasons is returned by CurrentClocksThrottleReasons().
+	ThrottleReasons gonvml.ClocksThrottleReasons
+	// Samples are returned by Samples(), filtered by their Time.
+	Samples map[gonvml.SamplingType][]gonvml.Sample
+
+	// PowerLimit, DefaultPowerLimit, MinPowerLimit and MaxPowerLimit are in
+	// milliwatts. MinPowerLimit and MaxPowerLimit are returned by
+	// PowerLimitConstraints().
+	PowerLimit        uint
+	DefaultPowerLimit uint
+	MinPowerLimit     uint
+	MaxPowerLimit     uint
+
+	PersistenceMode bool
+	ComputeMode     gonvml.ComputeMode
+	// EccMode and EccModePending are returned by EccMode(). SetEccMode()
+	// only changes EccModePending, like NVML does until the next reboot.
+	EccMode        bool
+	EccModePending bool
+	AccountingMode bool
+
+	// MemoryClock and GraphicsClock are the applications clocks in MHz,
+	// returned by ApplicationsClock().
+	MemoryClock   uint
+	GraphicsClock uint
+	// SupportedClocks maps each supported memory clock to the graphics
+	// clocks supported with it.
+	SupportedClocks map[uint][]uint
+
+	CurrPcieLinkGeneration uint
+	MaxPcieLinkGeneration  uint
+	CurrPcieLinkWidth      uint
+	MaxPcieLinkWidth       uint
+
+	ComputeRunningProcesses  []gonvml.ProcessInfo
+	GraphicsRunningProcesses []gonvml.ProcessInfo
+
+	// Errors overrides the result of methods by name, e.g.
+	// {"PowerUsage": gonvml.ErrNoPermission}.
+	Errors map[string]error
+}
+
+// device is the handle for a Device.
+type device struct {
+	d *Device
+}
+
+var _ gonvml.DeviceBackend = device{}
+
+func (h device) Samples(samplingType gonvml.SamplingType, since time.Duration) ([]gonvml.Sample, error) {
+	if err := h.d.Errors["Samples"]; err != nil {
+		return nil, err
+	}
+	start := time.Now().Add(-since)
+	var samples []gonvml.Sample
+	for _, s := range h.d.Samples[samplingType] {
+		if s.Time.After(start) {
+			samples = append(samples, s)
+		}
+	}
+	return samples, nil
+}
+
+func (h device) TemperatureThreshold(threshold gonvml.TemperatureThreshold) (uint, error) {
+	if err := h.d.Errors["TemperatureThreshold"]; err != nil {
+		return 0, err
+	}
+	switch threshold {
+	case gonvml.TemperatureThresholdShutdown:
+		return h.d.ShutdownTemperature, nil
+	case gonvml.TemperatureThresholdSlowdown:
+		return h.d.SlowdownTemperature, nil
+	}
+	return 0, errInvalidArgument
+}
+
+func (h device) PowerLimitConstraints() (uint, uint, error) {
+	if err := h.d.Errors["PowerLimitConstraints"]; err != nil {
+		return 0, 0, err
+	}
+	return h.d.MinPowerLimit, h.d.MaxPowerLimit, nil
+}
+
+func (h device) SetPowerLimit(milliwatts uint) error {
+	if err := h.d.Errors["SetPowerLimit"]; err != nil {
+		return err
+	}
+	h.d.PowerLimit = milliwatts
+	return nil
+}
+
+func (h device) EccMode() (bool, bool, error) {
+	if err := h.d.Errors["EccMode"]; err != nil {
+		return false, false, err
+	}
+	return h.d.EccMode, h.d.EccModePending, nil
+}
+
+func (h device) SetEccMode(enabled bool) (bool, error) {
+	if err := h.d.Errors["SetEccMode"]; err != nil {
+		return false, err
+	}
+	h.d.EccModePending = enabled
+	return h.d.EccMode != enabled, nil
+}
+
+func (h device) ApplicationsClock(clockType gonvml.ClockType) (uint, error) {
+	if err := h.d.Errors["ApplicationsClock"]; err != nil {
+		return 0, err
+	}
+	switch clockType {
+	case gonvml.ClockMemory:
+		return h.d.MemoryClock, nil
+	case gonvml.ClockGraphics:
+		return h.d.GraphicsClock, nil
+	}
+	return 0, gonvml.ErrNotSupported
+}
+
+func (h device) SupportedMemoryClocks() ([]uint, error) {
+	if err := h.d.Errors["SupportedMemoryClocks"]; err != nil {
+		return nil, err
+	}
+	clocks := make([]uint, 0, len(h.d.SupportedClocks))
+	for memory := range h.d.SupportedClocks {
+		clocks = append(clocks, memory)
+	}
+	// NVML lists the fastest clocks first.
+	sort.Slice(clocks, func(i, j int) bool { return clocks[i] > clocks[j] })
+	return clocks, nil
+}
+
+func (h device) SupportedGraphicsClocks(memoryClockMHz uint) ([]uint, error) {
+	if err := h.d.Errors["SupportedGraphicsClocks"]; err != nil {
+		return nil, err
+	}
+	clocks, ok := h.d.SupportedClocks[memoryClockMHz]
+	if !ok {
+		return nil, errNotFound
+	}
+	return clocks, nil
+}
+
+func (h device) SetApplicationsClocks(memoryClockMHz, graphicsClockMHz uint) error {
+	if err := h.d.Errors["SetApplicationsClocks"]; err != nil {
+		return err
+	}
+	h.d.MemoryClock = memoryClockMHz
+	h.d.GraphicsClock = graphicsClockMHz
+	return nil
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gpuconfig reconciles the configuration of the GPUs in a node with a
+// declarative spec.
+//
+// A Spec lists the desired settings per device selector. Compute() reads the
+// current state of every selected device and returns a Plan with only the
+// settings that differ. The plan can be printed as a dry run or applied:
+//
+//	plan, err := gpuconfig.Compute(spec)
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Print(plan)
+//	if !dryRun {
+//		err = plan.Apply()
+//	}
+//
+// Applying a plan computed against an already reconciled node is a no-op.
+// gonvml.Initialize() must have been called before Compute().
+package gpuconfig
+
+import (
+	"github.com/mindprince/gonvml"
+)
+
+// Spec is the desired configuration of the GPUs in a node.
+type Spec struct {
+	// Devices are applied in order. When several entries select the same
+	// device, settings of later entries override those of earlier ones.
+	Devices []DeviceSpec `json:"devices"`
+}
+
+// DeviceSpec is the desired configuration of the devices matched by Selector.
+type DeviceSpec struct {
+	Selector Selector `json:"selector"`
+	Config   Config   `json:"config"`
+}
+
+// Selector matches devices. All non-empty fields must match; an empty
+// Selector matches every device.
+type Selector struct {
+	// Indices are NVML device indices.
+	Indices []uint `json:"indices,omitempty"`
+	// UUIDs are device UUIDs, e.g. "GPU-5a8e4b1f-...".
+	UUIDs []string `json:"uuids,omitempty"`
+	// Name is the product name, e.g. "Tesla V100-SXM2-16GB".
+	Name string `json:"name,omitempty"`
+}
+
+func (s Selector) matches(idx uint, uuid, name string) bool {
+	if len(s.Indices) > 0 {
+		found := false
+		for _, i := range s.Indices {
+			if i == idx {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(s.UUIDs) > 0 {
+		found := false
+		for _, u := range s.UUIDs {
+			if u == uuid {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return s.Name == "" || s.Name == name
+}
+
+// Config holds the desired settings of a device. Nil fields are left
+// unchanged.
+type Config struct {
+	PersistenceMode *bool `json:"persistenceMode,omitempty"`
+	// ComputeMode is written by name, e.g. "Exclusive_Process".
+	ComputeMode *gonvml.ComputeMode `json:"computeMode,omitempty"`
+	// EccMode only takes effect after the next reboot.
+	EccMode *bool `json:"eccMode,omitempty"`
+	// PowerLimit is in milliwatts.
+	PowerLimit         *uint               `json:"powerLimit,omitempty"`
+	ApplicationsClocks *ApplicationsClocks `json:"applicationsClocks,omitempty"`
+	AccountingMode     *bool               `json:"accountingMode,omitempty"`
+}
+
+// ApplicationsClocks is a pair of applications clocks in MHz.
+type ApplicationsClocks struct {
+	Memory   uint `json:"memory"`
+	Graphics uint `json:"graphics"`
+}
+
+// merge overrides the settings of c with the non-nil settings of o.
+func (c *Config) merge(o Config) {
+	if o.PersistenceMode != nil {
+		c.PersistenceMode = o.PersistenceMode
+	}
+	if o.ComputeMode != nil {
+		c.ComputeMode = o.ComputeMode
+	}
+	if o.EccMode != nil {
+		c.EccMode = o.EccMode
+	}
+	if o.PowerLimit != nil {
+		c.PowerLimit = o.PowerLimit
+	}
+	if o.ApplicationsClocks != nil {
+		c.ApplicationsClocks = o.ApplicationsClocks
+	}
+	if o.AccountingMode != nil {
+		c.AccountingMode = o.AccountingMode
+	}
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gpuconfig
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mindprince/gonvml"
+	"github.com/mindprince/gonvml/gonvmltest"
+)
+
+func boolPtr(b bool) *bool { return &b }
+
+func uintPtr(u uint) *uint { return &u }
+
+func computeModePtr(m gonvml.ComputeMode) *gonvml.ComputeMode { return &m }
+
+func TestSelectorMatches(t *testing.T) {
+	tests := []struct {
+		selector Selector
+		want     bool
+	}{
+		{Selector{}, true},
+		{Selector{Indices: []uint{0, 1}}, true},
+		{Selector{Indices: []uint{2}}, false},
+		{Selector{UUIDs: []string{"GPU-1"}}, true},
+		{Selector{UUIDs: []string{"GPU-2"}}, false},
+		{Selector{Name: "Tesla V100-SXM2-16GB"}, true},
+		{Selector{Name: "Tesla P100-PCIE-16GB"}, false},
+		{Selector{Indices: []uint{1}, Name: "Tesla P100-PCIE-16GB"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.selector.matches(1, "GPU-1", "Tesla V100-SXM2-16GB"); got != tt.want {
+			t.Errorf("%+v.matches() = %v, want %v", tt.selector, got, tt.want)
+		}
+	}
+}
+
+func TestConfigMerge(t *testing.T) {
+	c := Config{PersistenceMode: boolPtr(true), PowerLimit: uintPtr(250000)}
+	c.merge(Config{PowerLimit: uintPtr(200000), AccountingMode: boolPtr(true)})
+	want := Config{PersistenceMode: boolPtr(true), PowerLimit: uintPtr(200000), AccountingMode: boolPtr(true)}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("merge() = %+v, want %+v", c, want)
+	}
+}
+
+func newFake() *gonvmltest.Backend {
+	return &gonvmltest.Backend{
+		Devices: []*gonvmltest.Device{
+			{UUID: "GPU-0", Name: "Tesla P100-PCIE-16GB", MinPowerLimit: 125000, MaxPowerLimit: 250000, PowerLimit: 250000},
+			{UUID: "GPU-1", Name: "Tesla V100-SXM2-16GB", MinPowerLimit: 150000, MaxPowerLimit: 300000, PowerLimit: 300000},
+		},
+	}
+}
+
+func TestCompute(t *testing.T) {
+	fake := newFake()
+	prev := gonvml.SetBackend(fake)
+	defer gonvml.SetBackend(prev)
+
+	spec := Spec{Devices: []DeviceSpec{
+		{Config: Config{PersistenceMode: boolPtr(true)}},
+		{
+			Selector: Selector{Name: "Tesla V100-SXM2-16GB"},
+			Config: Config{
+				ComputeMode: computeModePtr(gonvml.ComputeModeExclusiveProcess),
+				EccMode:     boolPtr(true),
+				PowerLimit:  uintPtr(200000),
+			},
+		},
+	}}
+	plan, err := Compute(spec)
+	if err != nil {
+		t.Fatalf("Compute() error: %v", err)
+	}
+	want := "GPU 0 (GPU-0): PersistenceMode: Disabled -> Enabled\n" +
+		"GPU 1 (GPU-1): PersistenceMode: Disabled -> Enabled\n" +
+		"GPU 1 (GPU-1): ComputeMode: Default -> Exclusive_Process\n" +
+		"GPU 1 (GPU-1): EccMode: Disabled -> Enabled (reboot required)\n" +
+		"GPU 1 (GPU-1): PowerLimit: 300000 mW -> 200000 mW\n"
+	if got := plan.String(); got != want {
+		t.Errorf("Compute() plan:\n%s\nwant:\n%s", got, want)
+	}
+	if !plan.RebootRequired() {
+		t.Error("RebootRequired() = false, want true")
+	}
+
+	if err := plan.Apply(); err != nil {
+		t.Fatalf("Apply() error: %v", err)
+	}
+	dev := fake.Devices[1]
+	if !dev.PersistenceMode || dev.ComputeMode != gonvml.ComputeModeExclusiveProcess || dev.PowerLimit != 200000 {
+		t.Errorf("Apply() left GPU 1 at %+v", dev)
+	}
+	if dev.EccMode || !dev.EccModePending {
+		t.Errorf("Apply() left ECC mode %v, pending %v, want disabled, pending enabled", dev.EccMode, dev.EccModePending)
+	}
+
+	// Only the reboot for the pending ECC change is left.
+	plan, err = Compute(spec)
+	if err != nil {
+		t.Fatalf("Compute() after Apply() error: %v", err)
+	}
+	want = "GPU 1 (GPU-1): EccMode: Disabled -> Enabled (pending, reboot required)\n"
+	if got := plan.String(); got != want || !plan.RebootRequired() {
+		t.Errorf("Compute() after Apply() = %q, want %q", got, want)
+	}
+	if err := plan.Apply(); err != nil {
+		t.Errorf("Apply() of a pending change error: %v", err)
+	}
+
+	// After the reboot the node is reconciled.
+	fake.Devices[1].EccMode = true
+	plan, err = Compute(spec)
+	if err != nil {
+		t.Fatalf("Compute() after reboot error: %v", err)
+	}
+	if got := plan.String(); got != "No changes.\n" || plan.RebootRequired() {
+		t.Errorf("Compute() after reboot = %q, want no changes", got)
+	}
+}
+
+func TestDiffEccMode(t *testing.T) {
+	tests := []struct {
+		current, pending, desired bool
+		want                      string
+	}{
+		{current: false, pending: false, desired: false},
+		{current: true, pending: true, desired: false, want: "EccMode: Enabled -> Disabled (reboot required)"},
+		// A change pending from a previous run only needs a reboot.
+		{current: true, pending: false, desired: false, want: "EccMode: Enabled -> Disabled (pending, reboot required)"},
+		// A pending change the spec no longer wants is reverted.
+		{current: false, pending: true, desired: false, want: "EccMode: Disabled -> Disabled (reboot required)"},
+	}
+	for _, tt := range tests {
+		fake := &gonvmltest.Backend{Devices: []*gonvmltest.Device{{EccMode: tt.current, EccModePending: tt.pending}}}
+		prev := gonvml.SetBackend(fake)
+		dev, err := gonvml.DeviceHandleByIndex(0)
+		if err != nil {
+			t.Fatalf("DeviceHandleByIndex(0) error: %v", err)
+		}
+		changes, err := diff(dev, Config{EccMode: boolPtr(tt.desired)})
+		gonvml.SetBackend(prev)
+		if err != nil {
+			t.Errorf("diff(%+v) error: %v", tt, err)
+			continue
+		}
+		var got string
+		if len(changes) > 0 {
+			got = strings.TrimPrefix(changes[0].String(), "GPU 0 (): ")
+		}
+		if len(changes) > 1 || got != tt.want {
+			t.Errorf("diff(%+v) = %v, want %q", tt, changes, tt.want)
+		}
+	}
+}
+
+func TestComputeModeText(t *testing.T) {
+	var config Config
+	if err := json.Unmarshal([]byte(`{"computeMode": "Exclusive_Process"}`), &config); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if config.ComputeMode == nil || *config.ComputeMode != gonvml.ComputeModeExclusiveProcess {
+		t.Errorf("Unmarshal() compute mode = %v, want Exclusive_Process", config.ComputeMode)
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	if got := string(data); got != `{"computeMode":"Exclusive_Process"}` {
+		t.Errorf("Marshal() = %s", got)
+	}
+	if err := json.Unmarshal([]byte(`{"computeMode": "Shared"}`), &config); err == nil {
+		t.Error("Unmarshal() of an unknown compute mode succeeded, want an error")
+	}
+}
+
+func TestApplyStopsAtFirstError(t *testing.T) {
+	fake := newFake()
+	prev := gonvml.SetBackend(fake)
+	defer gonvml.SetBackend(prev)
+
+	plan, err := Compute(Spec{Devices: []DeviceSpec{
+		{Config: Config{PowerLimit: uintPtr(275000), AccountingMode: boolPtr(true)}},
+	}})
+	if err != nil {
+		t.Fatalf("Compute() error: %v", err)
+	}
+	// 275 W is above the maximum of GPU 0.
+	err = plan.Apply()
+	if err == nil || !strings.HasPrefix(err.Error(), "GPU 0 (GPU-0): setting PowerLimit to 275000 mW: ") {
+		t.Errorf("Apply() error = %v, want the PowerLimit error of GPU 0", err)
+	}
+	if fake.Devices[0].AccountingMode || fake.Devices[1].PowerLimit != 300000 {
+		t.Error("Apply() continued after the first error")
+	}
+}
@@ -0,0 +1,266 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gpuconfig
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/mindprince/gonvml"
+)
+
+// Change is a single setting of a device that differs from the spec.
+type Change struct {
+	// Index and UUID identify the device.
+	Index uint
+	UUID  string
+	// Setting is the name of the setting, e.g. "ComputeMode".
+	Setting string
+	// Current and Desired are the human readable values of the setting.
+	Current string
+	Desired string
+	// RebootRequired is set if the change only takes effect after the next
+	// reboot.
+	RebootRequired bool
+
+	// apply is nil if the change is already pending and only needs a reboot.
+	apply func() error
+}
+
+func (c Change) String() string {
+	s := fmt.Sprintf("GPU %d (%s): %s: %s -> %s", c.Index, c.UUID, c.Setting, c.Current, c.Desired)
+	switch {
+	case c.apply == nil:
+		s += " (pending, reboot required)"
+	case c.RebootRequired:
+		s += " (reboot required)"
+	}
+	return s
+}
+
+// Plan is the list of changes needed to reconcile a node with a spec.
+type Plan struct {
+	Changes []Change
+}
+
+// String returns the plan with one change per line, suitable as dry-run
+// output.
+func (p Plan) String() string {
+	if len(p.Changes) == 0 {
+		return "No changes.\n"
+	}
+	var b bytes.Buffer
+	for _, c := range p.Changes {
+		b.WriteString(c.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// RebootRequired reports whether any change only takes effect after the next
+// reboot.
+func (p Plan) RebootRequired() bool {
+	for _, c := range p.Changes {
+		if c.RebootRequired {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply applies the changes in order, skipping the ones that are already
+// pending. It stops at the first change that fails. Computing a new plan
+// afterwards shows what is left to do.
+func (p Plan) Apply() error {
+	for _, c := range p.Changes {
+		if c.apply == nil {
+			continue
+		}
+		if err := c.apply(); err != nil {
+			return fmt.Errorf("GPU %d (%s): setting %s to %s: %v", c.Index, c.UUID, c.Setting, c.Desired, err)
+		}
+	}
+	return nil
+}
+
+// Compute reads the current configuration of the devices selected by spec
+// and returns the changes needed to match it. Devices not selected by any
+// entry of spec are left alone.
+func Compute(spec Spec) (Plan, error) {
+	count, err := gonvml.DeviceCount()
+	if err != nil {
+		return Plan{}, err
+	}
+	var plan Plan
+	for i := uint(0); i < count; i++ {
+		dev, err := gonvml.DeviceHandleByIndex(i)
+		if err != nil {
+			return Plan{}, fmt.Errorf("GPU %d: %v", i, err)
+		}
+		uuid, err := dev.UUID()
+		if err != nil {
+			return Plan{}, fmt.Errorf("GPU %d: %v", i, err)
+		}
+		name, err := dev.Name()
+		if err != nil {
+			return Plan{}, fmt.Errorf("GPU %d: %v", i, err)
+		}
+		var config Config
+		for _, s := range spec.Devices {
+			if s.Selector.matches(i, uuid, name) {
+				config.merge(s.Config)
+			}
+		}
+		changes, err := diff(dev, config)
+		if err != nil {
+			return Plan{}, fmt.Errorf("GPU %d (%s): %v", i, uuid, err)
+		}
+		for _, c := range changes {
+			c.Index = i
+			c.UUID = uuid
+			plan.Changes = append(plan.Changes, c)
+		}
+	}
+	return plan, nil
+}
+
+// diff returns the changes needed for dev to match config. Persistence mode
+// comes first so that the other settings survive the driver being unloaded
+// when no client is attached.
+func diff(dev gonvml.Device, config Config) ([]Change, error) {
+	var changes []Change
+	if config.PersistenceMode != nil {
+		desired := *config.PersistenceMode
+		current, err := dev.PersistenceMode()
+		if err != nil {
+			return nil, fmt.Errorf("reading PersistenceMode: %v", err)
+		}
+		if current != desired {
+			changes = append(changes, Change{
+				Setting: "PersistenceMode",
+				Current: enableState(current),
+				Desired: enableState(desired),
+				apply:   func() error { return dev.SetPersistenceMode(desired) },
+			})
+		}
+	}
+	if config.ComputeMode != nil {
+		desired := *config.ComputeMode
+		current, err := dev.ComputeMode()
+		if err != nil {
+			return nil, fmt.Errorf("reading ComputeMode: %v", err)
+		}
+		if current != desired {
+			changes = append(changes, Change{
+				Setting: "ComputeMode",
+				Current: current.String(),
+				Desired: desired.String(),
+				apply:   func() error { return dev.SetComputeMode(desired) },
+			})
+		}
+	}
+	if config.EccMode != nil {
+		desired := *config.EccMode
+		current, pending, err := dev.EccMode()
+		if err != nil {
+			return nil, fmt.Errorf("reading EccMode: %v", err)
+		}
+		switch {
+		case pending != desired:
+			changes = append(changes, Change{
+				Setting:        "EccMode",
+				Current:        enableState(current),
+				Desired:        enableState(desired),
+				RebootRequired: true,
+				apply: func() error {
+					_, err := dev.SetEccMode(desired)
+					return err
+				},
+			})
+		case current != desired:
+			// The change is already pending from a previous run and only
+			// needs a reboot.
+			changes = append(changes, Change{
+				Setting:        "EccMode",
+				Current:        enableState(current),
+				Desired:        enableState(desired),
+				RebootRequired: true,
+			})
+		}
+	}
+	if config.PowerLimit != nil {
+		desired := *config.PowerLimit
+		current, err := dev.PowerLimit()
+		if err != nil {
+			return nil, fmt.Errorf("reading PowerLimit: %v", err)
+		}
+		if current != desired {
+			changes = append(changes, Change{
+				Setting: "PowerLimit",
+				Current: fmt.Sprintf("%d mW", current),
+				Desired: fmt.Sprintf("%d mW", desired),
+				apply:   func() error { return dev.SetPowerLimit(desired) },
+			})
+		}
+	}
+	if config.ApplicationsClocks != nil {
+		desired := *config.ApplicationsClocks
+		var current ApplicationsClocks
+		var err error
+		if current.Memory, err = dev.ApplicationsClock(gonvml.ClockMemory); err != nil {
+			return nil, fmt.Errorf("reading ApplicationsClocks: %v", err)
+		}
+		if current.Graphics, err = dev.ApplicationsClock(gonvml.ClockGraphics); err != nil {
+			return nil, fmt.Errorf("reading ApplicationsClocks: %v", err)
+		}
+		if current != desired {
+			changes = append(changes, Change{
+				Setting: "ApplicationsClocks",
+				Current: current.String(),
+				Desired: desired.String(),
+				apply:   func() error { return dev.SetApplicationsClocks(desired.Memory, desired.Graphics) },
+			})
+		}
+	}
+	if config.AccountingMode != nil {
+		desired := *config.AccountingMode
+		current, err := dev.AccountingMode()
+		if err != nil {
+			return nil, fmt.Errorf("reading AccountingMode: %v", err)
+		}
+		if current != desired {
+			changes = append(changes, Change{
+				Setting: "AccountingMode",
+				Current: enableState(current),
+				Desired: enableState(desired),
+				apply:   func() error { return dev.SetAccountingMode(desired) },
+			})
+		}
+	}
+	return changes, nil
+}
+
+func (c ApplicationsClocks) String() string {
+	return fmt.Sprintf("%d MHz memory, %d MHz graphics", c.Memory, c.Graphics)
+}
+
+func enableState(enabled bool) string {
+	if enabled {
+		return gonvml.Enabled.String()
+	}
+	return gonvml.Disabled.String()
+}
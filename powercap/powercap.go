@@ -0,0 +1,292 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package powercap keeps the total power draw of the GPUs in a node under a
+// budget by adjusting their power limits.
+//
+// On every step the Controller reads the power draw, temperature and clocks
+// throttle reasons of each device:
+//
+//   - If the total draw is over the budget, the excess is taken from the
+//     power limits of the devices, in proportion to how far each one is above
+//     its minimum limit.
+//   - If the total draw is more than Config.Hysteresis below the budget, the
+//     spare power is shared between the devices that are being held back by
+//     their power limit and aren't too hot. Limits are only raised while their
+//     total stays within the budget.
+//   - Otherwise nothing is changed.
+//
+// No limit changes by more than Config.MaxStep per step, and limits always
+// stay within the constraints reported by the device.
+package powercap
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mindprince/gonvml"
+)
+
+// Config configures a Controller. All power values are in milliwatts.
+type Config struct {
+	// Budget is the maximum total power draw of all the GPUs in the node.
+	Budget uint
+	// Hysteresis is how far below Budget the total draw has to be before
+	// limits are raised again. It keeps the controller from oscillating
+	// around the budget.
+	Hysteresis uint
+	// MaxStep is the maximum change of the limit of a single device per step.
+	// 0 means no rate limit.
+	MaxStep uint
+	// MaxTemperature is the temperature in degrees Celsius at or above which
+	// the limit of a device is not raised. 0 disables the check.
+	MaxTemperature uint
+	// Interval is the time between steps in Run().
+	Interval time.Duration
+}
+
+// Adjustment is a change of the power limit of a device.
+type Adjustment struct {
+	Index uint
+	// From and To are the old and new limit in milliwatts.
+	From uint
+	To   uint
+}
+
+func (a Adjustment) String() string {
+	return fmt.Sprintf("GPU %d: power limit %d mW -> %d mW", a.Index, a.From, a.To)
+}
+
+// Controller adjusts the power limits of the devices of the node.
+type Controller struct {
+	config Config
+}
+
+// NewController returns a Controller for the devices of the node.
+// gonvml.Initialize() must have been called before the Controller is used.
+func NewController(config Config) (*Controller, error) {
+	if config.Budget == 0 {
+		return nil, errors.New("powercap: budget must be positive")
+	}
+	if config.Hysteresis >= config.Budget {
+		return nil, fmt.Errorf("powercap: hysteresis %d mW must be less than the budget %d mW", config.Hysteresis, config.Budget)
+	}
+	if config.Interval <= 0 {
+		return nil, errors.New("powercap: interval must be positive")
+	}
+	return &Controller{config: config}, nil
+}
+
+// Run calls Step() every Config.Interval until stop is closed or a step
+// fails. onStep, if not nil, is called with the adjustments of every step.
+func (c *Controller) Run(stop <-chan struct{}, onStep func([]Adjustment)) error {
+	ticker := time.NewTicker(c.config.Interval)
+	defer ticker.Stop()
+	for {
+		adjustments, err := c.Step()
+		if onStep != nil && len(adjustments) > 0 {
+			onStep(adjustments)
+		}
+		if err != nil {
+			return err
+		}
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// state is what a step knows about a device.
+type state struct {
+	idx      uint
+	dev      gonvml.Device
+	usage    uint
+	limit    uint
+	min      uint
+	max      uint
+	temp     uint
+	throttle gonvml.ClocksThrottleReasons
+}
+
+// Step reads the state of all devices once and adjusts their power limits.
+// Nothing is changed if the state of any device can't be read. It returns
+// the adjustments that were made.
+func (c *Controller) Step() ([]Adjustment, error) {
+	states, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+	var total uint
+	for _, s := range states {
+		total += s.usage
+	}
+	var targets []uint
+	switch {
+	case total > c.config.Budget:
+		targets = c.shed(states, total-c.config.Budget)
+	case total+c.config.Hysteresis < c.config.Budget:
+		targets = c.grant(states, c.config.Budget-c.config.Hysteresis-total)
+	default:
+		return nil, nil
+	}
+	var adjustments []Adjustment
+	for i, s := range states {
+		to := c.rateLimit(s, targets[i])
+		if to == s.limit {
+			continue
+		}
+		if err := s.dev.SetPowerLimit(to); err != nil {
+			return adjustments, fmt.Errorf("GPU %d: setting power limit to %d mW: %v", s.idx, to, err)
+		}
+		adjustments = append(adjustments, Adjustment{Index: s.idx, From: s.limit, To: to})
+	}
+	return adjustments, nil
+}
+
+func (c *Controller) read() ([]state, error) {
+	count, err := gonvml.DeviceCount()
+	if err != nil {
+		return nil, err
+	}
+	states := make([]state, count)
+	for i := range states {
+		s := &states[i]
+		s.idx = uint(i)
+		if s.dev, err = gonvml.DeviceHandleByIndex(s.idx); err != nil {
+			return nil, fmt.Errorf("GPU %d: %v", i, err)
+		}
+		if s.usage, err = s.dev.PowerUsage(); err != nil {
+			return nil, fmt.Errorf("GPU %d: reading power usage: %v", i, err)
+		}
+		if s.limit, err = s.dev.PowerLimit(); err != nil {
+			return nil, fmt.Errorf("GPU %d: reading power limit: %v", i, err)
+		}
+		if s.min, s.max, err = s.dev.PowerLimitConstraints(); err != nil {
+			return nil, fmt.Errorf("GPU %d: reading power limit constraints: %v", i, err)
+		}
+		if s.temp, err = s.dev.Temperature(); err != nil {
+			return nil, fmt.Errorf("GPU %d: reading temperature: %v", i, err)
+		}
+		if s.throttle, err = s.dev.CurrentClocksThrottleReasons(); err != nil {
+			return nil, fmt.Errorf("GPU %d: reading clocks throttle reasons: %v", i, err)
+		}
+	}
+	return states, nil
+}
+
+// shed returns the limits that take excess off the total draw. The excess is
+// taken in proportion to how far each device is above its minimum limit.
+// Devices drawing less than their limit are lowered from their draw, as
+// lowering the limit down to the draw doesn't save anything.
+func (c *Controller) shed(states []state, excess uint) []uint {
+	targets := make([]uint, len(states))
+	bases := make([]uint, len(states))
+	var headroom uint64
+	for i, s := range states {
+		bases[i] = s.limit
+		if s.usage < bases[i] {
+			bases[i] = s.usage
+		}
+		if bases[i] < s.min {
+			bases[i] = s.min
+		}
+		headroom += uint64(bases[i] - s.min)
+	}
+	for i, s := range states {
+		if headroom == 0 {
+			targets[i] = bases[i]
+			continue
+		}
+		// Round up so that the shares add up to at least the excess.
+		h := uint64(bases[i] - s.min)
+		share := (uint64(excess)*h + headroom - 1) / headroom
+		if share > h {
+			share = h
+		}
+		targets[i] = bases[i] - uint(share)
+	}
+	return targets
+}
+
+// grant returns the limits that share spare power between the devices that
+// are held back by their power limit. Each raise is taken out of the headroom
+// between the total of the limits and Config.Budget, so the total never goes
+// above the budget.
+func (c *Controller) grant(states []state, spare uint) []uint {
+	targets := make([]uint, len(states))
+	var limits uint
+	var eligible []int
+	for i, s := range states {
+		targets[i] = s.limit
+		limits += s.limit
+		if c.canRaise(s) {
+			eligible = append(eligible, i)
+		}
+	}
+	if len(eligible) == 0 || limits >= c.config.Budget {
+		return targets
+	}
+	headroom := c.config.Budget - limits
+	share := spare / uint(len(eligible))
+	for _, i := range eligible {
+		s := states[i]
+		raise := c.rateLimit(s, s.limit+share) - s.limit
+		if raise > headroom {
+			raise = headroom
+		}
+		targets[i] = s.limit + raise
+		headroom -= raise
+	}
+	return targets
+}
+
+func (c *Controller) canRaise(s state) bool {
+	if s.limit >= s.max {
+		return false
+	}
+	if s.throttle&gonvml.ClocksThrottleReasonSWPowerCap == 0 {
+		return false
+	}
+	// A device that is slowed down by the hardware, e.g. because it is too
+	// hot, won't make good use of more power.
+	if s.throttle&gonvml.ClocksThrottleReasonHWSlowdown != 0 {
+		return false
+	}
+	return c.config.MaxTemperature == 0 || s.temp < c.config.MaxTemperature
+}
+
+// rateLimit limits the change from s.limit to target to Config.MaxStep and
+// clamps the result to the constraints of the device.
+func (c *Controller) rateLimit(s state, target uint) uint {
+	if step := c.config.MaxStep; step > 0 {
+		if target > s.limit && target-s.limit > step {
+			target = s.limit + step
+		}
+		if target < s.limit && s.limit-target > step {
+			target = s.limit - step
+		}
+	}
+	if target < s.min {
+		target = s.min
+	}
+	if target > s.max {
+		target = s.max
+	}
+	return target
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package powercap
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mindprince/gonvml"
+	"github.com/mindprince/gonvml/gonvmltest"
+)
+
+// gpu is the state of a fake device with limits between 100 W and 300 W.
+type gpu struct {
+	usage, limit, temp uint
+	throttle           gonvml.ClocksThrottleReasons
+}
+
+const powerCapped = gonvml.ClocksThrottleReasonSWPowerCap
+
+func TestStep(t *testing.T) {
+	config := Config{Budget: 500000, Hysteresis: 20000, MaxTemperature: 80, Interval: time.Second}
+	tests := []struct {
+		name    string
+		maxStep uint
+		gpus    []gpu
+		want    []uint
+	}{
+		{
+			// 60 W over the budget, taken equally from the draw of both.
+			name: "shed",
+			gpus: []gpu{{usage: 280000, limit: 300000}, {usage: 280000, limit: 300000}},
+			want: []uint{250000, 250000},
+		},
+		{
+			// 80 W spare, shared between both.
+			name: "grant",
+			gpus: []gpu{{usage: 200000, limit: 200000, throttle: powerCapped}, {usage: 200000, limit: 200000, throttle: powerCapped}},
+			want: []uint{240000, 240000},
+		},
+		{
+			// The limits already add up to 450 W, only 50 W are left to grant.
+			name: "grant within budget",
+			gpus: []gpu{{usage: 200000, limit: 250000, throttle: powerCapped}, {usage: 200000, limit: 200000, throttle: powerCapped}},
+			want: []uint{290000, 210000},
+		},
+		{
+			name: "only power capped devices are raised",
+			gpus: []gpu{{usage: 200000, limit: 200000, throttle: powerCapped}, {usage: 150000, limit: 200000}},
+			want: []uint{300000, 200000},
+		},
+		{
+			name: "hysteresis",
+			gpus: []gpu{{usage: 245000, limit: 250000, throttle: powerCapped}, {usage: 245000, limit: 250000, throttle: powerCapped}},
+			want: []uint{250000, 250000},
+		},
+		{
+			name:    "max step",
+			maxStep: 25000,
+			gpus:    []gpu{{usage: 200000, limit: 200000, throttle: powerCapped}, {usage: 200000, limit: 200000, throttle: powerCapped}},
+			want:    []uint{225000, 225000},
+		},
+		{
+			name:    "max step when shedding",
+			maxStep: 25000,
+			gpus:    []gpu{{usage: 280000, limit: 300000}, {usage: 280000, limit: 300000}},
+			want:    []uint{275000, 275000},
+		},
+		{
+			name: "max temperature",
+			gpus: []gpu{{usage: 200000, limit: 200000, throttle: powerCapped}, {usage: 200000, limit: 200000, temp: 80, throttle: powerCapped}},
+			want: []uint{280000, 200000},
+		},
+	}
+	for _, tt := range tests {
+		fake := &gonvmltest.Backend{}
+		for _, g := range tt.gpus {
+			fake.Devices = append(fake.Devices, &gonvmltest.Device{
+				PowerUsage:      g.usage,
+				PowerLimit:      g.limit,
+				MinPowerLimit:   100000,
+				MaxPowerLimit:   300000,
+				Temperature:     g.temp,
+				ThrottleReasons: g.throttle,
+			})
+		}
+		prev := gonvml.SetBackend(fake)
+		config.MaxStep = tt.maxStep
+		c, err := NewController(config)
+		if err != nil {
+			t.Fatalf("NewController() error: %v", err)
+		}
+		_, err = c.Step()
+		gonvml.SetBackend(prev)
+		if err != nil {
+			t.Errorf("%s: Step() error: %v", tt.name, err)
+			continue
+		}
+		var got []uint
+		for _, d := range fake.Devices {
+			got = append(got, d.PowerLimit)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Step() set limits %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStepKeepsLimitsWithinBudget(t *testing.T) {
+	fake := &gonvmltest.Backend{}
+	for i := 0; i < 4; i++ {
+		fake.Devices = append(fake.Devices, &gonvmltest.Device{
+			PowerUsage:      100000,
+			PowerLimit:      100000,
+			MinPowerLimit:   100000,
+			MaxPowerLimit:   300000,
+			ThrottleReasons: powerCapped,
+		})
+	}
+	prev := gonvml.SetBackend(fake)
+	defer gonvml.SetBackend(prev)
+
+	const budget = 700000
+	c, err := NewController(Config{Budget: budget, Hysteresis: 10000, MaxStep: 50000, Interval: time.Second})
+	if err != nil {
+		t.Fatalf("NewController() error: %v", err)
+	}
+	for step := 0; step < 10; step++ {
+		if _, err := c.Step(); err != nil {
+			t.Fatalf("Step() error: %v", err)
+		}
+		// The devices draw everything they are allowed to.
+		var limits uint
+		for _, d := range fake.Devices {
+			d.PowerUsage = d.PowerLimit
+			limits += d.PowerLimit
+		}
+		if limits > budget {
+			t.Fatalf("after step %d the limits add up to %d mW, over the budget of %d mW", step, limits, budget)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProcessRecord is a ProcessInfo enriched with details about the process
+// that are read from procfs.
+type ProcessRecord struct {
+	ProcessInfo
+	// Name is the command name of the process, as in /proc/[pid]/comm.
+	Name string
+	// Cmdline holds the command line arguments of the process.
+	Cmdline []string
+	// UID is the real user ID of the process.
+	UID string
+	// User is the name of the user with UID. It is empty if the user can't
+	// be looked up.
+	User string
+	// StartTime is the time the process was started.
+	StartTime time.Time
+	// Err is the error that stopped Enrich() from reading the remaining
+	// details, or nil if all of them were read. os.IsNotExist(Err) means
+	// that the process has exited.
+	Err error
+}
+
+// Procfs reads process details from a procfs mount. The zero value reads from
+// /proc.
+type Procfs struct {
+	// Root is the procfs mount point. "/proc" is used if empty.
+	Root string
+	// ClockTicks is the number of clock ticks per second (USER_HZ) in which
+	// process start times are expressed. 100 is used if zero.
+	ClockTicks uint64
+	// LookupUser returns the name of the user with the given UID.
+	// user.LookupId() is used if nil.
+	LookupUser func(uid string) (string, error)
+}
+
+// Enrich returns a ProcessRecord for each of the given processes.
+// It is best effort: details that can't be read, e.g. because the process has
+// exited in the meantime, are left empty and the error is recorded in the
+// Err field of the record.
+func (p Procfs) Enrich(processes []ProcessInfo) []ProcessRecord {
+	records := make([]ProcessRecord, len(processes))
+	for i, process := range processes {
+		records[i], records[i].Err = p.Record(process)
+	}
+	return records
+}
+
+// Record returns a ProcessRecord for the given process. If a detail can't be
+// read, the error is returned along with the details that could be read.
+func (p Procfs) Record(process ProcessInfo) (ProcessRecord, error) {
+	record := ProcessRecord{ProcessInfo: process}
+
+	comm, err := ioutil.ReadFile(p.path(process.PID, "comm"))
+	if err != nil {
+		return record, err
+	}
+	record.Name = strings.TrimSuffix(string(comm), "\n")
+
+	cmdline, err := ioutil.ReadFile(p.path(process.PID, "cmdline"))
+	if err != nil {
+		return record, err
+	}
+	if cmdline = bytes.TrimSuffix(cmdline, []byte{0}); len(cmdline) > 0 {
+		record.Cmdline = strings.Split(string(cmdline), "\x00")
+	}
+
+	if record.UID, err = p.uid(process.PID); err != nil {
+		return record, err
+	}
+	if name, err := p.lookupUser(record.UID); err == nil {
+		record.User = name
+	}
+
+	if record.StartTime, err = p.startTime(process.PID); err != nil {
+		return record, err
+	}
+	return record, nil
+}
+
+func (p Procfs) root() string {
+	if p.Root == "" {
+		return "/proc"
+	}
+	return p.Root
+}
+
+func (p Procfs) path(pid uint, name string) string {
+	return filepath.Join(p.root(), strconv.FormatUint(uint64(pid), 10), name)
+}
+
+func (p Procfs) lookupUser(uid string) (string, error) {
+	if p.LookupUser != nil {
+		return p.LookupUser(uid)
+	}
+	u, err := user.LookupId(uid)
+	if err != nil {
+		return "", err
+	}
+	return u.Username, nil
+}
+
+// uid returns the real user ID from /proc/[pid]/status.
+func (p Procfs) uid(pid uint) (string, error) {
+	f, err := os.Open(p.path(pid, "status"))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "Uid:" {
+			return fields[1], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no Uid in %s", f.Name())
+}
+
+// startTime returns the start time of the process, computed from the start
+// time in clock ticks after boot in /proc/[pid]/stat and the boot time in
+// /proc/stat.
+func (p Procfs) startTime(pid uint) (time.Time, error) {
+	stat, err := ioutil.ReadFile(p.path(pid, "stat"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	// The command name is in parentheses and may itself contain spaces and
+	// parentheses, so only split what comes after the last ')'. starttime is
+	// the 22nd field overall and the 20th after the command name.
+	i := bytes.LastIndexByte(stat, ')')
+	if i < 0 {
+		return time.Time{}, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	fields := strings.Fields(string(stat[i+1:]))
+	if len(fields) < 20 {
+		return time.Time{}, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	ticks, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("malformed stat for pid %d: %v", pid, err)
+	}
+
+	bootTime, err := p.bootTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+	hz := p.ClockTicks
+	if hz == 0 {
+		hz = 100
+	}
+	// Multiplying ticks by time.Second first would overflow after a few years
+	// of uptime.
+	uptime := time.Duration(ticks/hz)*time.Second + time.Duration(ticks%hz)*time.Second/time.Duration(hz)
+	return bootTime.Add(uptime), nil
+}
+
+// bootTime returns the boot time from the btime line in /proc/stat.
+func (p Procfs) bootTime() (time.Time, error) {
+	f, err := os.Open(filepath.Join(p.root(), "stat"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "btime" {
+			btime, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("malformed btime in %s: %v", f.Name(), err)
+			}
+			return time.Unix(btime, 0), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return time.Time{}, err
+	}
+	return time.Time{}, fmt.Errorf("no btime in %s", f.Name())
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testProcfs = Procfs{
+	Root: "testdata/proc",
+	LookupUser: func(uid string) (string, error) {
+		if uid == "1000" {
+			return "alice", nil
+		}
+		return "", errors.New("unknown user")
+	},
+}
+
+func TestProcfsRecord(t *testing.T) {
+	btime := time.Unix(1500000000, 0)
+	tests := []struct {
+		pid  uint
+		want ProcessRecord
+	}{
+		{
+			pid: 1234,
+			want: ProcessRecord{
+				ProcessInfo: ProcessInfo{PID: 1234, UsedGPUMemory: 1 << 30},
+				Name:        "python3",
+				Cmdline:     []string{"python3", "train.py", "--epochs=10"},
+				UID:         "1000",
+				User:        "alice",
+				StartTime:   btime.Add(123450 * time.Millisecond),
+			},
+		},
+		{
+			// The command name contains spaces and parentheses, and the
+			// process started after 5 years of uptime.
+			pid: 4321,
+			want: ProcessRecord{
+				ProcessInfo: ProcessInfo{PID: 4321, UsedGPUMemory: 1 << 30},
+				Name:        "my (odd) proc",
+				UID:         "0",
+				StartTime:   btime.Add(5*365*24*time.Hour + 500*time.Millisecond),
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, err := testProcfs.Record(ProcessInfo{PID: tt.pid, UsedGPUMemory: 1 << 30})
+		if err != nil {
+			t.Errorf("Record(%d) error: %v", tt.pid, err)
+			continue
+		}
+		if !got.StartTime.Equal(tt.want.StartTime) {
+			t.Errorf("Record(%d).StartTime = %v, want %v", tt.pid, got.StartTime, tt.want.StartTime)
+		}
+		got.StartTime, tt.want.StartTime = time.Time{}, time.Time{}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Record(%d) = %+v, want %+v", tt.pid, got, tt.want)
+		}
+	}
+}
+
+func TestProcfsEnrich(t *testing.T) {
+	records := testProcfs.Enrich([]ProcessInfo{{PID: 1234}, {PID: 999}})
+	if len(records) != 2 {
+		t.Fatalf("Enrich() returned %d records, want 2", len(records))
+	}
+	if records[0].Err != nil || records[0].Name != "python3" {
+		t.Errorf("Enrich() record for pid 1234 = %+v, want name python3 and no error", records[0])
+	}
+	if !os.IsNotExist(records[1].Err) {
+		t.Errorf("Enrich() error for exited pid 999 = %v, want a not exist error", records[1].Err)
+	}
+	if records[1].PID != 999 || records[1].Name != "" {
+		t.Errorf("Enrich() record for exited pid 999 = %+v, want only the PID set", records[1])
+	}
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+// ThermalHeadroom returns how many degrees Celsius this GPU is below its
+// slowdown and shutdown thresholds respectively. A negative value means that
+// the GPU is already past that threshold.
+//
+// Absolute temperatures mean different things on different products, so the
+// headroom is usually a better signal to alert on than Temperature().
+func (d Device) ThermalHeadroom() (int, int, error) {
+	temperature, err := d.Temperature()
+	if err != nil {
+		return 0, 0, err
+	}
+	slowdown, err := d.TemperatureThreshold(TemperatureThresholdSlowdown)
+	if err != nil {
+		return 0, 0, err
+	}
+	shutdown, err := d.TemperatureThreshold(TemperatureThresholdShutdown)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(slowdown) - int(temperature), int(shutdown) - int(temperature), nil
+}
@@ -0,0 +1,711 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The types in this file mirror the enums and structs in nvml.h. They are
+// defined in plain Go so that they are available whether or not the binary
+// is built with cgo.
+
+// EnableState is a generic enabled/disabled state. It mirrors
+// nvmlEnableState_t.
+type EnableState uint
+
+const (
+	Disabled EnableState = 0
+	Enabled  EnableState = 1
+)
+
+func (s EnableState) String() string {
+	switch s {
+	case Disabled:
+		return "Disabled"
+	case Enabled:
+		return "Enabled"
+	}
+	return "Unknown"
+}
+
+// BrandType is the brand of a device. It mirrors nvmlBrandType_t.
+type BrandType uint
+
+const (
+	BrandUnknown BrandType = 0
+	BrandQuadro  BrandType = 1
+	BrandTesla   BrandType = 2
+	BrandNVS     BrandType = 3
+	BrandGRID    BrandType = 4
+	BrandGeForce BrandType = 5
+)
+
+func (b BrandType) String() string {
+	switch b {
+	case BrandQuadro:
+		return "Quadro"
+	case BrandTesla:
+		return "Tesla"
+	case BrandNVS:
+		return "NVS"
+	case BrandGRID:
+		return "GRID"
+	case BrandGeForce:
+		return "GeForce"
+	}
+	return "Unknown"
+}
+
+// BridgeChipType is the type of a bridge chip. It mirrors nvmlBridgeChipType_t.
+type BridgeChipType uint
+
+const (
+	BridgeChipPLX  BridgeChipType = 0
+	BridgeChipBRO4 BridgeChipType = 1
+)
+
+func (t BridgeChipType) String() string {
+	switch t {
+	case BridgeChipPLX:
+		return "PLX"
+	case BridgeChipBRO4:
+		return "BRO4"
+	}
+	return "Unknown"
+}
+
+// BridgeChip describes a bridge chip on a multi-GPU board.
+type BridgeChip struct {
+	Type BridgeChipType
+	// FirmwareVersion is the firmware version of the bridge chip.
+	// 0 means that the version is unavailable.
+	FirmwareVersion uint
+}
+
+// ComputeMode is the compute mode of a device. It mirrors nvmlComputeMode_t.
+type ComputeMode uint
+
+const (
+	// ComputeModeDefault allows multiple contexts per device.
+	ComputeModeDefault ComputeMode = 0
+	// ComputeModeExclusiveThread is no longer supported by the driver.
+	ComputeModeExclusiveThread ComputeMode = 1
+	// ComputeModeProhibited allows no contexts per device.
+	ComputeModeProhibited ComputeMode = 2
+	// ComputeModeExclusiveProcess allows only one context per device, usable
+	// from multiple threads at a time.
+	ComputeModeExclusiveProcess ComputeMode = 3
+)
+
+func (m ComputeMode) String() string {
+	switch m {
+	case ComputeModeDefault:
+		return "Default"
+	case ComputeModeExclusiveThread:
+		return "Exclusive_Thread"
+	case ComputeModeProhibited:
+		return "Prohibited"
+	case ComputeModeExclusiveProcess:
+		return "Exclusive_Process"
+	}
+	return "Unknown"
+}
+
+// MarshalText encodes the compute mode as its String() name, e.g.
+// "Exclusive_Process".
+func (m ComputeMode) MarshalText() ([]byte, error) {
+	if m > ComputeModeExclusiveProcess {
+		return nil, fmt.Errorf("nvml: unknown compute mode %d", uint(m))
+	}
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText decodes a compute mode from its String() name.
+func (m *ComputeMode) UnmarshalText(text []byte) error {
+	for mode := ComputeModeDefault; mode <= ComputeModeExclusiveProcess; mode++ {
+		if string(text) == mode.String() {
+			*m = mode
+			return nil
+		}
+	}
+	return fmt.Errorf("nvml: unknown compute mode %q", text)
+}
+
+// TemperatureThreshold is a temperature threshold type. It mirrors
+// nvmlTemperatureThresholds_t.
+type TemperatureThreshold uint
+
+const (
+	// TemperatureThresholdShutdown is the temperature at which the GPU shuts
+	// down for hardware protection.
+	TemperatureThresholdShutdown TemperatureThreshold = 0
+	// TemperatureThresholdSlowdown is the temperature at which the GPU begins
+	// to slow down.
+	TemperatureThresholdSlowdown TemperatureThreshold = 1
+)
+
+func (t TemperatureThreshold) String() string {
+	switch t {
+	case TemperatureThresholdShutdown:
+		return "Shutdown"
+	case TemperatureThresholdSlowdown:
+		return "Slowdown"
+	}
+	return "Unknown"
+}
+
+// EncoderType is the type of a video encoder. It mirrors nvmlEncoderType_t.
+type EncoderType uint
+
+const (
+	EncoderH264 EncoderType = 0
+	EncoderHEVC EncoderType = 1
+)
+
+func (t EncoderType) String() string {
+	switch t {
+	case EncoderH264:
+		return "H264"
+	case EncoderHEVC:
+		return "HEVC"
+	}
+	return "Unknown"
+}
+
+// EncoderSession describes an active video encoder session.
+type EncoderSession struct {
+	SessionID uint
+	// PID is the ID of the process owning the session.
+	PID uint
+	// VgpuInstanceID is the ID of the vGPU instance owning the session. It is
+	// only valid on vGPU hosts and is zero otherwise.
+	VgpuInstanceID uint
+	Codec          EncoderType
+	// HResolution and VResolution are the current horizontal and vertical
+	// encode resolution.
+	HResolution uint
+	VResolution uint
+	// AverageFPS is the moving average of encoded frames per second.
+	AverageFPS uint
+	// AverageLatency is the moving average encode latency in microseconds.
+	AverageLatency uint
+}
+
+// UnitInfo is the static information associated with an S-class unit.
+type UnitInfo struct {
+	Name            string
+	ID              string
+	Serial          string
+	FirmwareVersion string
+}
+
+// PsuInfo describes the power supply of an S-class unit.
+type PsuInfo struct {
+	// State is "Normal", or "Abnormal" followed by one or more causes.
+	State string
+	// Current is the PSU current in amperes.
+	Current uint
+	// Voltage is the PSU voltage in volts.
+	Voltage uint
+	// Power is the PSU power draw in watts.
+	Power uint
+}
+
+// FanState is the state of a fan in an S-class unit. It mirrors
+// nvmlFanState_t.
+type FanState uint
+
+const (
+	FanNormal FanState = 0
+	FanFailed FanState = 1
+)
+
+func (s FanState) String() string {
+	switch s {
+	case FanNormal:
+		return "Normal"
+	case FanFailed:
+		return "Failed"
+	}
+	return "Unknown"
+}
+
+// UnitFan describes a single fan in an S-class unit.
+type UnitFan struct {
+	// Speed is the fan speed in RPM.
+	Speed uint
+	State FanState
+}
+
+// UnitTemperatureType is the type of a temperature reading of an S-class
+// unit.
+type UnitTemperatureType uint
+
+const (
+	UnitTemperatureIntake  UnitTemperatureType = 0
+	UnitTemperatureExhaust UnitTemperatureType = 1
+	UnitTemperatureBoard   UnitTemperatureType = 2
+)
+
+func (t UnitTemperatureType) String() string {
+	switch t {
+	case UnitTemperatureIntake:
+		return "Intake"
+	case UnitTemperatureExhaust:
+		return "Exhaust"
+	case UnitTemperatureBoard:
+		return "Board"
+	}
+	return "Unknown"
+}
+
+// LedColor is the color of the LED of an S-class unit. It mirrors
+// nvmlLedColor_t.
+type LedColor uint
+
+const (
+	// LedGreen indicates good health.
+	LedGreen LedColor = 0
+	// LedAmber indicates a problem.
+	LedAmber LedColor = 1
+)
+
+func (c LedColor) String() string {
+	switch c {
+	case LedGreen:
+		return "Green"
+	case LedAmber:
+		return "Amber"
+	}
+	return "Unknown"
+}
+
+// LedState is the state of the LED of an S-class unit.
+type LedState struct {
+	Color LedColor
+	// Cause is a description of the problem if Color is LedAmber.
+	Cause string
+}
+
+// VgpuType identifies a vGPU type, i.e. a vGPU profile such as
+// "GRID M60-2Q". It mirrors nvmlVgpuTypeId_t.
+type VgpuType uint
+
+// Resolution is a display resolution in pixels.
+type Resolution struct {
+	Width  uint
+	Height uint
+}
+
+// VgpuTypeInfo describes a vGPU type on a particular device.
+type VgpuTypeInfo struct {
+	Type  VgpuType
+	Class string
+	Name  string
+	// DeviceID and SubsystemID are the PCI device and subsystem IDs, each
+	// combined with the corresponding vendor ID in a single 32 bit value.
+	DeviceID    uint64
+	SubsystemID uint64
+	// FramebufferSize is in bytes.
+	FramebufferSize uint64
+	// MaxResolutions holds the maximum resolution of each display head.
+	MaxResolutions []Resolution
+	License        string
+	// FrameRateLimit is 0 if the frame rate limiter is turned off.
+	FrameRateLimit uint
+	// MaxInstances is the maximum number of instances of this type that can
+	// be created on the device.
+	MaxInstances uint
+}
+
+// VgpuInstance identifies a vGPU instance running on a device. It mirrors
+// nvmlVgpuInstance_t.
+type VgpuInstance uint
+
+// VMIDType is the format of the ID of a VM. It mirrors nvmlVgpuVmIdType_t.
+type VMIDType uint
+
+const (
+	VMIDDomainID VMIDType = 0
+	VMIDUUID     VMIDType = 1
+)
+
+func (t VMIDType) String() string {
+	switch t {
+	case VMIDDomainID:
+		return "Domain_ID"
+	case VMIDUUID:
+		return "UUID"
+	}
+	return "Unknown"
+}
+
+// VgpuUtilizationSample is a utilization sample of a vGPU instance. The
+// utilization values are percentages.
+type VgpuUtilizationSample struct {
+	Instance VgpuInstance
+	Time     time.Time
+	SM       uint
+	Memory   uint
+	Encoder  uint
+	Decoder  uint
+}
+
+// VgpuProcessUtilizationSample is a utilization sample of a process running
+// in a vGPU instance. The utilization values are percentages.
+type VgpuProcessUtilizationSample struct {
+	Instance    VgpuInstance
+	PID         uint
+	ProcessName string
+	Time        time.Time
+	SM          uint
+	Memory      uint
+	Encoder     uint
+	Decoder     uint
+}
+
+// GridLicenseFeatureCode identifies a GRID licensable feature. It mirrors
+// nvmlGridLicenseFeatureCode_t.
+type GridLicenseFeatureCode uint
+
+const (
+	GridLicenseFeatureVGPU         GridLicenseFeatureCode = 1
+	GridLicenseFeatureVWorkstation GridLicenseFeatureCode = 2
+)
+
+func (c GridLicenseFeatureCode) String() string {
+	switch c {
+	case GridLicenseFeatureVGPU:
+		return "vGPU"
+	case GridLicenseFeatureVWorkstation:
+		return "Virtual_Workstation"
+	}
+	return "Unknown"
+}
+
+// GridLicensableFeature describes a GRID licensable feature.
+type GridLicensableFeature struct {
+	Code     GridLicenseFeatureCode
+	Licensed bool
+	// Info is the name of the feature, e.g. "GRID-Virtual-WS,2.0".
+	Info string
+}
+
+// PciInfo holds the PCI attributes of a device.
+type PciInfo struct {
+	// BusID is the domain:bus:device.function PCI identifier.
+	BusID string
+	// Domain is the PCI domain on which the device's bus resides.
+	Domain uint
+	// Bus is the bus on which the device resides.
+	Bus uint
+	// Device is the device's ID on the bus.
+	Device uint
+	// PciDeviceID is the combined 16-bit device ID and 16-bit vendor ID.
+	PciDeviceID uint
+	// PciSubSystemID is the 32-bit sub system device ID.
+	PciSubSystemID uint
+}
+
+// PcieUtilCounter selects the direction of a PCIe throughput reading. It
+// mirrors nvmlPcieUtilCounter_t.
+type PcieUtilCounter uint
+
+const (
+	PcieUtilTXBytes PcieUtilCounter = 0
+	PcieUtilRXBytes PcieUtilCounter = 1
+)
+
+func (c PcieUtilCounter) String() string {
+	switch c {
+	case PcieUtilTXBytes:
+		return "TX"
+	case PcieUtilRXBytes:
+		return "RX"
+	}
+	return "Unknown"
+}
+
+// SamplingType is the type of samples to query with Device.Samples(). It
+// mirrors nvmlSamplingType_t.
+type SamplingType uint
+
+const (
+	// TotalPowerSamples is the total power drawn by the GPU in milliwatts.
+	TotalPowerSamples SamplingType = 0
+	// GPUUtilizationSamples is the percent of time during which one or more
+	// kernels were executing on the GPU.
+	GPUUtilizationSamples SamplingType = 1
+	// MemoryUtilizationSamples is the percent of time during which global
+	// (device) memory was being read or written.
+	MemoryUtilizationSamples SamplingType = 2
+	// EncoderUtilizationSamples is the percent of time during which the video
+	// encoder was busy.
+	EncoderUtilizationSamples SamplingType = 3
+	// DecoderUtilizationSamples is the percent of time during which the video
+	// decoder was busy.
+	DecoderUtilizationSamples SamplingType = 4
+	// ProcessorClockSamples is the processor clock in MHz.
+	ProcessorClockSamples SamplingType = 5
+	// MemoryClockSamples is the memory clock in MHz.
+	MemoryClockSamples SamplingType = 6
+)
+
+func (t SamplingType) String() string {
+	switch t {
+	case TotalPowerSamples:
+		return "Total_Power"
+	case GPUUtilizationSamples:
+		return "GPU_Utilization"
+	case MemoryUtilizationSamples:
+		return "Memory_Utilization"
+	case EncoderUtilizationSamples:
+		return "Encoder_Utilization"
+	case DecoderUtilizationSamples:
+		return "Decoder_Utilization"
+	case ProcessorClockSamples:
+		return "Processor_Clock"
+	case MemoryClockSamples:
+		return "Memory_Clock"
+	}
+	return "Unknown"
+}
+
+// Sample is a single sample collected by the driver.
+type Sample struct {
+	Time time.Time
+	// Value is decoded from whichever value type NVML reported for the
+	// samples.
+	Value float64
+}
+
+// DriverModel is the driver model of a device on Windows. It mirrors
+// nvmlDriverModel_t.
+type DriverModel uint
+
+const (
+	// DriverWDDM treats the GPU as a display device.
+	DriverWDDM DriverModel = 0
+	// DriverWDM treats the GPU as a generic device. Also known as TCC.
+	DriverWDM DriverModel = 1
+)
+
+func (m DriverModel) String() string {
+	switch m {
+	case DriverWDDM:
+		return "WDDM"
+	case DriverWDM:
+		return "WDM"
+	}
+	return "Unknown"
+}
+
+// GPUOperationMode is the GPU operation mode (GOM) of a device. It mirrors
+// nvmlGpuOperationMode_t.
+type GPUOperationMode uint
+
+const (
+	// GOMAllOn has everything enabled and running at full speed.
+	GOMAllOn GPUOperationMode = 0
+	// GOMCompute is designed for running only compute tasks. Graphics
+	// operations are not allowed.
+	GOMCompute GPUOperationMode = 1
+	// GOMLowDP is designed for running graphics applications that don't
+	// require high bandwidth double precision.
+	GOMLowDP GPUOperationMode = 2
+)
+
+func (m GPUOperationMode) String() string {
+	switch m {
+	case GOMAllOn:
+		return "All_On"
+	case GOMCompute:
+		return "Compute"
+	case GOMLowDP:
+		return "Low_DP"
+	}
+	return "Unknown"
+}
+
+// VirtualizationMode is the virtualization mode of a device. It mirrors
+// nvmlGpuVirtualizationMode_t.
+type VirtualizationMode uint
+
+const (
+	// VirtualizationModeNone is a bare-metal GPU.
+	VirtualizationModeNone VirtualizationMode = 0
+	// VirtualizationModePassthrough is a GPU passed through to a VM.
+	VirtualizationModePassthrough VirtualizationMode = 1
+	// VirtualizationModeVGPU is a vGPU inside a VM.
+	VirtualizationModeVGPU VirtualizationMode = 2
+	// VirtualizationModeHostVGPU is a GPU on a hypervisor in vGPU mode.
+	VirtualizationModeHostVGPU VirtualizationMode = 3
+	// VirtualizationModeHostVSGA is a GPU on a hypervisor in vSGA mode.
+	VirtualizationModeHostVSGA VirtualizationMode = 4
+)
+
+func (m VirtualizationMode) String() string {
+	switch m {
+	case VirtualizationModeNone:
+		return "None"
+	case VirtualizationModePassthrough:
+		return "Pass-Through"
+	case VirtualizationModeVGPU:
+		return "VGPU"
+	case VirtualizationModeHostVGPU:
+		return "Host_VGPU"
+	case VirtualizationModeHostVSGA:
+		return "Host_VSGA"
+	}
+	return "Unknown"
+}
+
+// ProcessInfo describes a process running on a device.
+type ProcessInfo struct {
+	PID uint
+	// UsedGPUMemory is the amount of GPU memory used by the process in bytes.
+	// Under WDDM it is always the maximum uint64 value, i.e. not available.
+	UsedGPUMemory uint64
+}
+
+// ClockType is a clock domain. It mirrors nvmlClockType_t.
+type ClockType uint
+
+const (
+	ClockGraphics ClockType = 0
+	ClockSM       ClockType = 1
+	ClockMemory   ClockType = 2
+	ClockVideo    ClockType = 3
+)
+
+func (t ClockType) String() string {
+	switch t {
+	case ClockGraphics:
+		return "Graphics"
+	case ClockSM:
+		return "SM"
+	case ClockMemory:
+		return "Memory"
+	case ClockVideo:
+		return "Video"
+	}
+	return "Unknown"
+}
+
+// EccCounterType selects a set of ECC error counters. It mirrors
+// nvmlEccCounterType_t.
+type EccCounterType uint
+
+const (
+	// EccVolatile counters are reset each time the driver loads.
+	EccVolatile EccCounterType = 0
+	// EccAggregate counters persist across reboots, i.e. for the lifetime
+	// of the device.
+	EccAggregate EccCounterType = 1
+)
+
+func (t EccCounterType) String() string {
+	switch t {
+	case EccVolatile:
+		return "Volatile"
+	case EccAggregate:
+		return "Aggregate"
+	}
+	return "Unknown"
+}
+
+// RestrictedAPI is a set of APIs whose use can be restricted to root. It
+// mirrors nvmlRestrictedAPI_t.
+type RestrictedAPI uint
+
+const (
+	// RestrictedAPISetApplicationClocks covers the APIs that change the
+	// applications clocks, e.g. Device.SetApplicationsClocks().
+	RestrictedAPISetApplicationClocks RestrictedAPI = 0
+	// RestrictedAPISetAutoBoostedClocks covers the APIs that enable or
+	// disable auto boosted clocks, e.g. Device.SetAutoBoostedClocksEnabled().
+	RestrictedAPISetAutoBoostedClocks RestrictedAPI = 1
+)
+
+func (a RestrictedAPI) String() string {
+	switch a {
+	case RestrictedAPISetApplicationClocks:
+		return "SetApplicationClocks"
+	case RestrictedAPISetAutoBoostedClocks:
+		return "SetAutoBoostedClocks"
+	}
+	return "Unknown"
+}
+
+// ClocksThrottleReasons is a bitmask of the reasons why the clocks of a
+// device are being throttled. It mirrors the nvmlClocksThrottleReason*
+// defines.
+type ClocksThrottleReasons uint64
+
+const (
+	// ClocksThrottleReasonGPUIdle is set when nothing is running on the GPU
+	// and the clocks are dropping to idle.
+	ClocksThrottleReasonGPUIdle ClocksThrottleReasons = 0x01
+	// ClocksThrottleReasonApplicationsClocksSetting is set when the clocks
+	// are limited by the applications clocks.
+	ClocksThrottleReasonApplicationsClocksSetting ClocksThrottleReasons = 0x02
+	// ClocksThrottleReasonSWPowerCap is set when the software power scaling
+	// algorithm keeps the power draw under the power limit.
+	ClocksThrottleReasonSWPowerCap ClocksThrottleReasons = 0x04
+	// ClocksThrottleReasonHWSlowdown is set when the hardware is slowing the
+	// clocks down by a factor of 2 or more, e.g. because the temperature is
+	// too high or an external power brake is asserted.
+	ClocksThrottleReasonHWSlowdown ClocksThrottleReasons = 0x08
+	// ClocksThrottleReasonSyncBoost is set when the GPU is held back by other
+	// GPUs in its sync boost group.
+	ClocksThrottleReasonSyncBoost ClocksThrottleReasons = 0x10
+
+	ClocksThrottleReasonNone ClocksThrottleReasons = 0
+)
+
+var clocksThrottleReasonNames = []struct {
+	reason ClocksThrottleReasons
+	name   string
+}{
+	{ClocksThrottleReasonGPUIdle, "GPUIdle"},
+	{ClocksThrottleReasonApplicationsClocksSetting, "ApplicationsClocksSetting"},
+	{ClocksThrottleReasonSWPowerCap, "SWPowerCap"},
+	{ClocksThrottleReasonHWSlowdown, "HWSlowdown"},
+	{ClocksThrottleReasonSyncBoost, "SyncBoost"},
+}
+
+// String returns the names of the reasons set in r separated by "|", or
+// "None" if no reason is set.
+func (r ClocksThrottleReasons) String() string {
+	if r == ClocksThrottleReasonNone {
+		return "None"
+	}
+	var names []string
+	for _, n := range clocksThrottleReasonNames {
+		if r&n.reason != 0 {
+			names = append(names, n.name)
+			r &^= n.reason
+		}
+	}
+	if r != 0 {
+		names = append(names, fmt.Sprintf("0x%x", uint64(r)))
+	}
+	return strings.Join(names, "|")
+}